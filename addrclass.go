@@ -0,0 +1,178 @@
+package asndb
+
+import "net/netip"
+
+// AddrClass is the purpose of an IP address according to the IANA special-purpose address registries.
+type AddrClass int
+
+const (
+	// AddrPublic is any address not listed in the special-purpose table.
+	AddrPublic AddrClass = iota
+	// AddrPrivate is private-use space, such as RFC 1918 and unique local addresses.
+	AddrPrivate
+	// AddrShared is the shared address space used for carrier-grade NAT.
+	AddrShared
+	// AddrLoopback is loopback space.
+	AddrLoopback
+	// AddrLinkLocal is link-local space.
+	AddrLinkLocal
+	// AddrDocumentation is for use in documentation and sample code.
+	AddrDocumentation
+	// AddrBenchmarking is for benchmarking network devices.
+	AddrBenchmarking
+	// AddrMulticast is multicast space.
+	AddrMulticast
+	// AddrTranslation is used by transition mechanisms between IPv4 and IPv6, such as NAT64 and 6to4.
+	AddrTranslation
+	// AddrProtocol is assigned to specific protocols and anycast services, such as AS112.
+	AddrProtocol
+	// AddrReserved is reserved or unspecified space that must not be routed.
+	AddrReserved
+)
+
+func (c AddrClass) String() string {
+	switch c {
+	case AddrPublic:
+		return "public"
+	case AddrPrivate:
+		return "private"
+	case AddrShared:
+		return "shared"
+	case AddrLoopback:
+		return "loopback"
+	case AddrLinkLocal:
+		return "link-local"
+	case AddrDocumentation:
+		return "documentation"
+	case AddrBenchmarking:
+		return "benchmarking"
+	case AddrMulticast:
+		return "multicast"
+	case AddrTranslation:
+		return "translation"
+	case AddrProtocol:
+		return "protocol"
+	case AddrReserved:
+		return "reserved"
+	}
+	return "unknown"
+}
+
+// SpecialPrefix is an entry of the special-purpose address table.
+type SpecialPrefix struct {
+	Prefix netip.Prefix
+	Class  AddrClass
+	// Name is the address block name in the IANA registry.
+	Name string
+	// Reference is the RFC defining the prefix.
+	Reference string
+}
+
+// specialPrefixes holds the IANA IPv4 and IPv6 special-purpose address registries,
+// plus the multicast blocks of the IANA address space registries.
+// Prefixes may nest, lookups return the most specific entry.
+var specialPrefixes = []SpecialPrefix{
+	{Prefix: netip.MustParsePrefix("0.0.0.0/8"), Class: AddrReserved, Name: "This network", Reference: "RFC 791"},
+	{Prefix: netip.MustParsePrefix("0.0.0.0/32"), Class: AddrReserved, Name: "This host on this network", Reference: "RFC 1122"},
+	{Prefix: netip.MustParsePrefix("10.0.0.0/8"), Class: AddrPrivate, Name: "Private-Use", Reference: "RFC 1918"},
+	{Prefix: netip.MustParsePrefix("100.64.0.0/10"), Class: AddrShared, Name: "Shared Address Space", Reference: "RFC 6598"},
+	{Prefix: netip.MustParsePrefix("127.0.0.0/8"), Class: AddrLoopback, Name: "Loopback", Reference: "RFC 1122"},
+	{Prefix: netip.MustParsePrefix("169.254.0.0/16"), Class: AddrLinkLocal, Name: "Link Local", Reference: "RFC 3927"},
+	{Prefix: netip.MustParsePrefix("172.16.0.0/12"), Class: AddrPrivate, Name: "Private-Use", Reference: "RFC 1918"},
+	{Prefix: netip.MustParsePrefix("192.0.0.0/24"), Class: AddrProtocol, Name: "IETF Protocol Assignments", Reference: "RFC 6890"},
+	{Prefix: netip.MustParsePrefix("192.0.0.0/29"), Class: AddrTranslation, Name: "IPv4 Service Continuity Prefix", Reference: "RFC 7335"},
+	{Prefix: netip.MustParsePrefix("192.0.0.8/32"), Class: AddrProtocol, Name: "IPv4 dummy address", Reference: "RFC 7600"},
+	{Prefix: netip.MustParsePrefix("192.0.0.9/32"), Class: AddrProtocol, Name: "Port Control Protocol Anycast", Reference: "RFC 7723"},
+	{Prefix: netip.MustParsePrefix("192.0.0.10/32"), Class: AddrProtocol, Name: "Traversal Using Relays around NAT Anycast", Reference: "RFC 8155"},
+	{Prefix: netip.MustParsePrefix("192.0.0.170/32"), Class: AddrTranslation, Name: "NAT64/DNS64 Discovery", Reference: "RFC 8880"},
+	{Prefix: netip.MustParsePrefix("192.0.0.171/32"), Class: AddrTranslation, Name: "NAT64/DNS64 Discovery", Reference: "RFC 8880"},
+	{Prefix: netip.MustParsePrefix("192.0.2.0/24"), Class: AddrDocumentation, Name: "Documentation (TEST-NET-1)", Reference: "RFC 5737"},
+	{Prefix: netip.MustParsePrefix("192.31.196.0/24"), Class: AddrProtocol, Name: "AS112-v4", Reference: "RFC 7535"},
+	{Prefix: netip.MustParsePrefix("192.52.193.0/24"), Class: AddrProtocol, Name: "AMT", Reference: "RFC 7450"},
+	{Prefix: netip.MustParsePrefix("192.88.99.0/24"), Class: AddrTranslation, Name: "Deprecated (6to4 Relay Anycast)", Reference: "RFC 7526"},
+	{Prefix: netip.MustParsePrefix("192.168.0.0/16"), Class: AddrPrivate, Name: "Private-Use", Reference: "RFC 1918"},
+	{Prefix: netip.MustParsePrefix("192.175.48.0/24"), Class: AddrProtocol, Name: "Direct Delegation AS112 Service", Reference: "RFC 7534"},
+	{Prefix: netip.MustParsePrefix("198.18.0.0/15"), Class: AddrBenchmarking, Name: "Benchmarking", Reference: "RFC 2544"},
+	{Prefix: netip.MustParsePrefix("198.51.100.0/24"), Class: AddrDocumentation, Name: "Documentation (TEST-NET-2)", Reference: "RFC 5737"},
+	{Prefix: netip.MustParsePrefix("203.0.113.0/24"), Class: AddrDocumentation, Name: "Documentation (TEST-NET-3)", Reference: "RFC 5737"},
+	{Prefix: netip.MustParsePrefix("224.0.0.0/4"), Class: AddrMulticast, Name: "Multicast", Reference: "RFC 5771"},
+	{Prefix: netip.MustParsePrefix("240.0.0.0/4"), Class: AddrReserved, Name: "Reserved", Reference: "RFC 1112"},
+	{Prefix: netip.MustParsePrefix("255.255.255.255/32"), Class: AddrReserved, Name: "Limited Broadcast", Reference: "RFC 919"},
+
+	{Prefix: netip.MustParsePrefix("::/128"), Class: AddrReserved, Name: "Unspecified Address", Reference: "RFC 4291"},
+	{Prefix: netip.MustParsePrefix("::1/128"), Class: AddrLoopback, Name: "Loopback Address", Reference: "RFC 4291"},
+	{Prefix: netip.MustParsePrefix("::ffff:0:0/96"), Class: AddrTranslation, Name: "IPv4-mapped Address", Reference: "RFC 4291"},
+	{Prefix: netip.MustParsePrefix("64:ff9b::/96"), Class: AddrTranslation, Name: "IPv4-IPv6 Translat.", Reference: "RFC 6052"},
+	{Prefix: netip.MustParsePrefix("64:ff9b:1::/48"), Class: AddrTranslation, Name: "IPv4-IPv6 Translat.", Reference: "RFC 8215"},
+	{Prefix: netip.MustParsePrefix("100::/64"), Class: AddrReserved, Name: "Discard-Only Address Block", Reference: "RFC 6666"},
+	{Prefix: netip.MustParsePrefix("2001::/23"), Class: AddrProtocol, Name: "IETF Protocol Assignments", Reference: "RFC 2928"},
+	{Prefix: netip.MustParsePrefix("2001::/32"), Class: AddrTranslation, Name: "TEREDO", Reference: "RFC 4380"},
+	{Prefix: netip.MustParsePrefix("2001:1::1/128"), Class: AddrProtocol, Name: "Port Control Protocol Anycast", Reference: "RFC 7723"},
+	{Prefix: netip.MustParsePrefix("2001:1::2/128"), Class: AddrProtocol, Name: "Traversal Using Relays around NAT Anycast", Reference: "RFC 8155"},
+	{Prefix: netip.MustParsePrefix("2001:2::/48"), Class: AddrBenchmarking, Name: "Benchmarking", Reference: "RFC 5180"},
+	{Prefix: netip.MustParsePrefix("2001:3::/32"), Class: AddrProtocol, Name: "AMT", Reference: "RFC 7450"},
+	{Prefix: netip.MustParsePrefix("2001:4:112::/48"), Class: AddrProtocol, Name: "AS112-v6", Reference: "RFC 7535"},
+	{Prefix: netip.MustParsePrefix("2001:10::/28"), Class: AddrReserved, Name: "Deprecated (previously ORCHID)", Reference: "RFC 4843"},
+	{Prefix: netip.MustParsePrefix("2001:20::/28"), Class: AddrProtocol, Name: "ORCHIDv2", Reference: "RFC 7343"},
+	{Prefix: netip.MustParsePrefix("2001:30::/28"), Class: AddrProtocol, Name: "Drone Remote ID Protocol Entity Tags (DETs) Prefix", Reference: "RFC 9374"},
+	{Prefix: netip.MustParsePrefix("2001:db8::/32"), Class: AddrDocumentation, Name: "Documentation", Reference: "RFC 3849"},
+	{Prefix: netip.MustParsePrefix("2002::/16"), Class: AddrTranslation, Name: "6to4", Reference: "RFC 3056"},
+	{Prefix: netip.MustParsePrefix("2620:4f:8000::/48"), Class: AddrProtocol, Name: "Direct Delegation AS112 Service", Reference: "RFC 7534"},
+	{Prefix: netip.MustParsePrefix("3fff::/20"), Class: AddrDocumentation, Name: "Documentation", Reference: "RFC 9637"},
+	{Prefix: netip.MustParsePrefix("5f00::/16"), Class: AddrProtocol, Name: "Segment Routing (SRv6) SIDs", Reference: "RFC 9602"},
+	{Prefix: netip.MustParsePrefix("fc00::/7"), Class: AddrPrivate, Name: "Unique-Local", Reference: "RFC 4193"},
+	{Prefix: netip.MustParsePrefix("fe80::/10"), Class: AddrLinkLocal, Name: "Link-Local Unicast", Reference: "RFC 4291"},
+	{Prefix: netip.MustParsePrefix("ff00::/8"), Class: AddrMulticast, Name: "Multicast", Reference: "RFC 4291"},
+}
+
+// SpecialPrefixes returns a copy of the special-purpose address table.
+func SpecialPrefixes() []SpecialPrefix {
+	return clone(specialPrefixes)
+}
+
+// LookupSpecialAddr returns the most specific special-purpose table entry containing ip.
+// Bool is false for public addresses.
+func LookupSpecialAddr(ip netip.Addr) (SpecialPrefix, bool) {
+	ip = ip.WithZone("")
+	best := -1
+	for i, p := range specialPrefixes {
+		if p.Prefix.Contains(ip) && (best < 0 || p.Prefix.Bits() > specialPrefixes[best].Prefix.Bits()) {
+			best = i
+		}
+	}
+	if best < 0 {
+		return SpecialPrefix{}, false
+	}
+	return specialPrefixes[best], true
+}
+
+// ClassifyAddr returns the class of ip.
+func ClassifyAddr(ip netip.Addr) AddrClass {
+	p, _ := LookupSpecialAddr(ip)
+	return p.Class
+}
+
+// LookupResult is the result of ASList.Lookup.
+type LookupResult struct {
+	// AS and Found are the result of ASList.Find.
+	AS    AS
+	Found bool
+	// Special is the special-purpose table entry containing the address, only valid if IsSpecial is set.
+	Special   SpecialPrefix
+	IsSpecial bool
+}
+
+// Class returns the class of the looked up address.
+func (l LookupResult) Class() AddrClass {
+	return l.Special.Class
+}
+
+// Lookup finds the AS zone for ip like Find, and classifies ip against the special-purpose address table.
+// This tells apart addresses missing from the dataset because they are unrouted public space,
+// from private, loopback, documentation and other special-purpose addresses.
+func (r *ASList) Lookup(ip netip.Addr) LookupResult {
+	var l LookupResult
+	l.AS, l.Found = r.Find(ip)
+	l.Special, l.IsSpecial = LookupSpecialAddr(ip)
+	return l
+}
@@ -0,0 +1,91 @@
+package asndb
+
+import (
+	"net/netip"
+	"testing"
+)
+
+func TestLookupSpecialAddr(t *testing.T) {
+	tests := []struct {
+		ip       string
+		want     AddrClass
+		wantName string
+		wantRef  string
+	}{
+		{ip: "1.1.1.1", want: AddrPublic},
+		{ip: "10.1.2.3", want: AddrPrivate, wantName: "Private-Use", wantRef: "RFC 1918"},
+		{ip: "172.31.255.255", want: AddrPrivate, wantRef: "RFC 1918"},
+		{ip: "172.32.0.0", want: AddrPublic},
+		{ip: "100.64.0.1", want: AddrShared, wantRef: "RFC 6598"},
+		{ip: "127.0.0.1", want: AddrLoopback},
+		{ip: "169.254.1.1", want: AddrLinkLocal},
+		{ip: "192.0.2.1", want: AddrDocumentation, wantName: "Documentation (TEST-NET-1)"},
+		{ip: "198.19.0.1", want: AddrBenchmarking},
+		{ip: "224.0.0.251", want: AddrMulticast},
+		{ip: "240.0.0.1", want: AddrReserved},
+		{ip: "255.255.255.255", want: AddrReserved, wantName: "Limited Broadcast"},
+		//the most specific entry wins
+		{ip: "0.0.0.0", want: AddrReserved, wantName: "This host on this network"},
+		{ip: "0.1.0.0", want: AddrReserved, wantName: "This network"},
+		{ip: "192.0.0.9", want: AddrProtocol, wantName: "Port Control Protocol Anycast", wantRef: "RFC 7723"},
+		{ip: "192.0.0.100", want: AddrProtocol, wantName: "IETF Protocol Assignments"},
+		{ip: "2606:4700::1111", want: AddrPublic},
+		{ip: "::", want: AddrReserved, wantName: "Unspecified Address"},
+		{ip: "::1", want: AddrLoopback},
+		{ip: "::ffff:10.0.0.1", want: AddrTranslation, wantName: "IPv4-mapped Address"},
+		{ip: "2001:db8::1", want: AddrDocumentation, wantRef: "RFC 3849"},
+		{ip: "2001::1", want: AddrTranslation, wantName: "TEREDO"},
+		{ip: "2001:1::1", want: AddrProtocol, wantName: "Port Control Protocol Anycast"},
+		{ip: "2001:1::5", want: AddrProtocol, wantName: "IETF Protocol Assignments"},
+		{ip: "fd00::1", want: AddrPrivate, wantName: "Unique-Local"},
+		{ip: "fe80::1%eth0", want: AddrLinkLocal},
+		{ip: "ff02::1", want: AddrMulticast},
+	}
+	for _, tt := range tests {
+		ip := netip.MustParseAddr(tt.ip)
+		p, ok := LookupSpecialAddr(ip)
+		if ok != (tt.want != AddrPublic) || p.Class != tt.want || ClassifyAddr(ip) != tt.want {
+			t.Errorf("LookupSpecialAddr(%v) = %v, %v, want %v", tt.ip, p, ok, tt.want)
+		}
+		if tt.wantName != "" && p.Name != tt.wantName {
+			t.Errorf("LookupSpecialAddr(%v).Name = %v, want %v", tt.ip, p.Name, tt.wantName)
+		}
+		if tt.wantRef != "" && p.Reference != tt.wantRef {
+			t.Errorf("LookupSpecialAddr(%v).Reference = %v, want %v", tt.ip, p.Reference, tt.wantRef)
+		}
+	}
+
+	if _, ok := LookupSpecialAddr(netip.Addr{}); ok {
+		t.Errorf("LookupSpecialAddr(invalid) found, want not found")
+	}
+	for _, p := range SpecialPrefixes() {
+		if p.Prefix != p.Prefix.Masked() || p.Reference == "" {
+			t.Errorf("SpecialPrefixes() entry %v is not canonical or misses a reference", p)
+		}
+	}
+}
+
+func TestASList_Lookup(t *testing.T) {
+	r := NewASList([]AS{
+		{StartIP: netip.MustParseAddr("1.0.0.0"), EndIP: netip.MustParseAddr("1.0.0.255"), ASNumber: 13335},
+		{StartIP: netip.MustParseAddr("192.88.99.0"), EndIP: netip.MustParseAddr("192.88.99.255"), ASNumber: 6939},
+	})
+	tests := []struct {
+		ip        string
+		wantASN   ASN
+		wantFound bool
+		wantClass AddrClass
+	}{
+		{ip: "1.0.0.1", wantASN: 13335, wantFound: true, wantClass: AddrPublic},
+		{ip: "1.0.1.1", wantClass: AddrPublic},
+		{ip: "192.168.1.1", wantClass: AddrPrivate},
+		{ip: "192.88.99.1", wantASN: 6939, wantFound: true, wantClass: AddrTranslation},
+	}
+	for _, tt := range tests {
+		l := r.Lookup(netip.MustParseAddr(tt.ip))
+		if l.Found != tt.wantFound || l.AS.ASNumber != tt.wantASN || l.Class() != tt.wantClass ||
+			l.IsSpecial != (tt.wantClass != AddrPublic) {
+			t.Errorf("ASList.Lookup(%v) = %+v, want %v, %v, %v", tt.ip, l, tt.wantASN, tt.wantFound, tt.wantClass)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package asndb
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ASN is a 32-bit autonomous system number.
+type ASN uint32
+
+// ParseASN parses an ASN in asplain "13335" or asdot "1.10" notation, optionally prefixed by "AS" in any case.
+// Asdot numbers are made of two 16-bit halves, "1.10" is 65546.
+// The returned error wraps ErrInvalidASN.
+func ParseASN(s string) (ASN, error) {
+	text := s
+	if len(text) >= 2 && strings.EqualFold(text[:2], "AS") {
+		text = text[2:]
+	}
+
+	if high, low, dot := strings.Cut(text, "."); dot {
+		h, err := parseDigits(high, 16)
+		if err != nil {
+			return 0, fmt.Errorf("%w %q", ErrInvalidASN, s)
+		}
+		l, err := parseDigits(low, 16)
+		if err != nil {
+			return 0, fmt.Errorf("%w %q", ErrInvalidASN, s)
+		}
+		return ASN(h<<16 | l), nil
+	}
+
+	n, err := parseDigits(text, 32)
+	if err != nil {
+		return 0, fmt.Errorf("%w %q", ErrInvalidASN, s)
+	}
+	return ASN(n), nil
+}
+
+// parseDigits parses a decimal number of at most bitSize bits, rejecting signs.
+func parseDigits(s string, bitSize int) (uint64, error) {
+	if s == "" || s[0] < '0' || s[0] > '9' {
+		return 0, strconv.ErrSyntax
+	}
+	return strconv.ParseUint(s, 10, bitSize)
+}
+
+// MustParseASN is like ParseASN but panics on error.
+func MustParseASN(s string) ASN {
+	a, err := ParseASN(s)
+	if err != nil {
+		panic(err)
+	}
+	return a
+}
+
+// String returns the ASN in asplain notation prefixed by "AS", such as "AS13335".
+func (a ASN) String() string {
+	return "AS" + strconv.FormatUint(uint64(a), 10)
+}
+
+// Asdot returns the ASN in asdot notation as defined by RFC 5396,
+// ASN above 65535 are written as two 16-bit halves such as "1.10", smaller ASN are written as is.
+func (a ASN) Asdot() string {
+	if a <= 0xffff {
+		return strconv.FormatUint(uint64(a), 10)
+	}
+	return strconv.FormatUint(uint64(a>>16), 10) + "." + strconv.FormatUint(uint64(a&0xffff), 10)
+}
+
+// MarshalText implements encoding.TextMarshaler, using the String form.
+func (a ASN) MarshalText() ([]byte, error) {
+	return []byte(a.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, accepting every form ParseASN does.
+func (a *ASN) UnmarshalText(text []byte) error {
+	n, err := ParseASN(string(text))
+	if err != nil {
+		return err
+	}
+	*a = n
+	return nil
+}
+
+// MarshalJSON encodes the ASN as a JSON number.
+func (a ASN) MarshalJSON() ([]byte, error) {
+	return strconv.AppendUint(nil, uint64(a), 10), nil
+}
+
+// UnmarshalJSON decodes a JSON number, or a JSON string in any form ParseASN accepts.
+func (a *ASN) UnmarshalJSON(b []byte) error {
+	text := string(b)
+	if text == "null" {
+		return nil
+	}
+	if len(text) >= 2 && text[0] == '"' && text[len(text)-1] == '"' {
+		unquoted, err := strconv.Unquote(text)
+		if err != nil {
+			return fmt.Errorf("%w %s", ErrInvalidASN, text)
+		}
+		text = unquoted
+	} else if strings.Contains(text, ".") {
+		//bare numbers must be asplain, 1.10 is a float rather than an asdot ASN
+		return fmt.Errorf("%w %s", ErrInvalidASN, text)
+	}
+	return a.UnmarshalText([]byte(text))
+}
@@ -0,0 +1,115 @@
+package asndb
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestParseASN(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    ASN
+		wantErr bool
+	}{
+		{in: "13335", want: 13335},
+		{in: "AS13335", want: 13335},
+		{in: "as13335", want: 13335},
+		{in: "As13335", want: 13335},
+		{in: "0", want: 0},
+		{in: "4294967295", want: 4294967295},
+		{in: "1.10", want: 65546},
+		{in: "AS1.10", want: 65546},
+		{in: "0.13335", want: 13335},
+		{in: "65535.65535", want: 4294967295},
+		{in: "4294967296", wantErr: true},
+		{in: "65536.0", wantErr: true},
+		{in: "1.65536", wantErr: true},
+		{in: "1.", wantErr: true},
+		{in: ".1", wantErr: true},
+		{in: "1.2.3", wantErr: true},
+		{in: "-1", wantErr: true},
+		{in: "+1", wantErr: true},
+		{in: "AS", wantErr: true},
+		{in: "ASAS1", wantErr: true},
+		{in: " 1", wantErr: true},
+		{in: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseASN(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseASN(%q) = %v, %v, want %v, error %t", tt.in, got, err, tt.want, tt.wantErr)
+		}
+		if err != nil && !errors.Is(err, ErrInvalidASN) {
+			t.Errorf("ParseASN(%q) error = %v, want ErrInvalidASN", tt.in, err)
+		}
+	}
+}
+
+func TestASN_Format(t *testing.T) {
+	tests := []struct {
+		asn       ASN
+		wantPlain string
+		wantDot   string
+	}{
+		{asn: 0, wantPlain: "AS0", wantDot: "0"},
+		{asn: 13335, wantPlain: "AS13335", wantDot: "13335"},
+		{asn: 65535, wantPlain: "AS65535", wantDot: "65535"},
+		{asn: 65536, wantPlain: "AS65536", wantDot: "1.0"},
+		{asn: 65546, wantPlain: "AS65546", wantDot: "1.10"},
+		{asn: 4294967295, wantPlain: "AS4294967295", wantDot: "65535.65535"},
+	}
+	for _, tt := range tests {
+		if got := tt.asn.String(); got != tt.wantPlain {
+			t.Errorf("ASN(%d).String() = %v, want %v", uint32(tt.asn), got, tt.wantPlain)
+		}
+		if got := tt.asn.Asdot(); got != tt.wantDot {
+			t.Errorf("ASN(%d).Asdot() = %v, want %v", uint32(tt.asn), got, tt.wantDot)
+		}
+		for _, s := range []string{tt.wantPlain, tt.wantDot} {
+			if got := MustParseASN(s); got != tt.asn {
+				t.Errorf("ParseASN(%q) = %d, want %d", s, got, tt.asn)
+			}
+		}
+	}
+}
+
+func TestASN_JSON(t *testing.T) {
+	type doc struct {
+		ASN   ASN         `json:"asn"`
+		Count map[ASN]int `json:"count"`
+	}
+	b, err := json.Marshal(doc{ASN: 65546, Count: map[ASN]int{13335: 1}})
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	if want := `{"asn":65546,"count":{"AS13335":1}}`; string(b) != want {
+		t.Errorf("json.Marshal() = %s, want %s", b, want)
+	}
+	var d doc
+	if err := json.Unmarshal(b, &d); err != nil || d.ASN != 65546 || d.Count[13335] != 1 {
+		t.Errorf("json.Unmarshal() = %v, %v", d, err)
+	}
+
+	tests := []struct {
+		in      string
+		want    ASN
+		wantErr bool
+	}{
+		{in: `13335`, want: 13335},
+		{in: `"AS13335"`, want: 13335},
+		{in: `"1.10"`, want: 65546},
+		{in: `null`},
+		{in: `1.10`, wantErr: true},
+		{in: `-1`, wantErr: true},
+		{in: `4294967296`, wantErr: true},
+		{in: `"AS"`, wantErr: true},
+	}
+	for _, tt := range tests {
+		var got ASN
+		err := json.Unmarshal([]byte(tt.in), &got)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("json.Unmarshal(%s) = %v, %v, want %v, error %t", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
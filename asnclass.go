@@ -0,0 +1,117 @@
+package asndb
+
+import "sort"
+
+// ASNClass is the purpose of an ASN according to the IANA special-purpose AS numbers registry.
+type ASNClass int
+
+const (
+	// ASNPublic is any ASN not listed in the special-purpose table, which may be routed on the internet.
+	ASNPublic ASNClass = iota
+	// ASNReserved is reserved and must not be used, such as AS0 and AS65535.
+	ASNReserved
+	// ASNPrivate is for private use, like private IP address space.
+	ASNPrivate
+	// ASNDocumentation is for use in documentation and sample code.
+	ASNDocumentation
+	// ASNTrans is AS_TRANS, which stands in for 32-bit ASN towards BGP speakers that only support 16-bit ASN.
+	ASNTrans
+)
+
+func (c ASNClass) String() string {
+	switch c {
+	case ASNPublic:
+		return "public"
+	case ASNReserved:
+		return "reserved"
+	case ASNPrivate:
+		return "private"
+	case ASNDocumentation:
+		return "documentation"
+	case ASNTrans:
+		return "as_trans"
+	}
+	return "unknown"
+}
+
+// SpecialASN is an entry of the special-purpose ASN table, covering First to Last inclusive.
+type SpecialASN struct {
+	First, Last ASN
+	Class       ASNClass
+	// Reference is the RFC defining the range, or empty for ranges reserved by IANA.
+	Reference string
+}
+
+// specialASNs is the IANA special-purpose AS numbers registry, plus the ranges IANA holds as reserved.
+// Entries are sorted and do not overlap.
+var specialASNs = []SpecialASN{
+	{First: 0, Last: 0, Class: ASNReserved, Reference: "RFC 7607"},
+	{First: 23456, Last: 23456, Class: ASNTrans, Reference: "RFC 6793"},
+	{First: 64496, Last: 64511, Class: ASNDocumentation, Reference: "RFC 5398"},
+	{First: 64512, Last: 65534, Class: ASNPrivate, Reference: "RFC 6996"},
+	{First: 65535, Last: 65535, Class: ASNReserved, Reference: "RFC 7300"},
+	{First: 65536, Last: 65551, Class: ASNDocumentation, Reference: "RFC 5398"},
+	{First: 65552, Last: 131071, Class: ASNReserved},
+	{First: 4200000000, Last: 4294967294, Class: ASNPrivate, Reference: "RFC 6996"},
+	{First: 4294967295, Last: 4294967295, Class: ASNReserved, Reference: "RFC 7300"},
+}
+
+// SpecialASNs returns a copy of the special-purpose ASN table.
+func SpecialASNs() []SpecialASN {
+	return clone(specialASNs)
+}
+
+// LookupSpecialASN returns the special-purpose ASN table entry containing asn.
+// Bool is false for public ASN.
+func LookupSpecialASN(asn ASN) (SpecialASN, bool) {
+	i := sort.Search(len(specialASNs), func(i int) bool {
+		return specialASNs[i].Last >= asn
+	})
+	if i < len(specialASNs) && specialASNs[i].First <= asn {
+		return specialASNs[i], true
+	}
+	return SpecialASN{}, false
+}
+
+// Classify returns the class of asn.
+func Classify(asn ASN) ASNClass {
+	s, _ := LookupSpecialASN(asn)
+	return s.Class
+}
+
+// IsPublic reports if the ASN is not special-purpose, see Classify.
+func (a ASN) IsPublic() bool {
+	return Classify(a) == ASNPublic
+}
+
+// IsPublic reports if the zone belongs to a public ASN.
+// Zones of non-public ASN are typically unrouted space, such as the "Not routed" AS0 zones of iptoasn.
+func (a AS) IsPublic() bool {
+	return a.ASNumber.IsPublic()
+}
+
+// PublicOnly returns the zones of s belonging to a public ASN, in the same order.
+// Use it to drop non-public zones before creating an ASList or ASNMap.
+func PublicOnly(s []AS) []AS {
+	out := make([]AS, 0, len(s))
+	for _, as := range s {
+		if as.IsPublic() {
+			out = append(out, as)
+		}
+	}
+	return out
+}
+
+// NonPublicASN returns every ASN in the map that is not public, sorted.
+func (m *ASNMap) NonPublicASN() []ASN {
+	var s []ASN
+	for asn := range m.m {
+		if !asn.IsPublic() {
+			s = append(s, asn)
+		}
+	}
+	sort.Slice(s, func(i, j int) bool {
+		return s[i] < s[j]
+	})
+	return s
+}
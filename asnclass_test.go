@@ -0,0 +1,90 @@
+package asndb
+
+import (
+	"net/netip"
+	"reflect"
+	"testing"
+)
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		asn  ASN
+		want ASNClass
+	}{
+		{asn: 0, want: ASNReserved},
+		{asn: 1, want: ASNPublic},
+		{asn: 13335, want: ASNPublic},
+		{asn: 23456, want: ASNTrans},
+		{asn: 64495, want: ASNPublic},
+		{asn: 64496, want: ASNDocumentation},
+		{asn: 64511, want: ASNDocumentation},
+		{asn: 64512, want: ASNPrivate},
+		{asn: 65534, want: ASNPrivate},
+		{asn: 65535, want: ASNReserved},
+		{asn: 65536, want: ASNDocumentation},
+		{asn: 65551, want: ASNDocumentation},
+		{asn: 65552, want: ASNReserved},
+		{asn: 131071, want: ASNReserved},
+		{asn: 131072, want: ASNPublic},
+		{asn: 4199999999, want: ASNPublic},
+		{asn: 4200000000, want: ASNPrivate},
+		{asn: 4294967294, want: ASNPrivate},
+		{asn: 4294967295, want: ASNReserved},
+	}
+	for _, tt := range tests {
+		if got := Classify(tt.asn); got != tt.want {
+			t.Errorf("Classify(%v) = %v, want %v", tt.asn, got, tt.want)
+		}
+		if got := tt.asn.IsPublic(); got != (tt.want == ASNPublic) {
+			t.Errorf("%v.IsPublic() = %v, want %v", tt.asn, got, tt.want == ASNPublic)
+		}
+	}
+
+	if s, ok := LookupSpecialASN(64512); !ok || s.First != 64512 || s.Last != 65534 || s.Reference != "RFC 6996" {
+		t.Errorf("LookupSpecialASN(64512) = %v, %v", s, ok)
+	}
+	if s, ok := LookupSpecialASN(13335); ok {
+		t.Errorf("LookupSpecialASN(13335) = %v, %v, want not found", s, ok)
+	}
+
+	table := SpecialASNs()
+	for i, s := range table {
+		if s.First > s.Last || i > 0 && table[i-1].Last >= s.First {
+			t.Errorf("SpecialASNs()[%d] = %v is not sorted or overlaps", i, s)
+		}
+	}
+}
+
+func TestPublicOnly(t *testing.T) {
+	zones := []AS{
+		{StartIP: netip.MustParseAddr("1.0.0.0"), EndIP: netip.MustParseAddr("1.0.0.255"), ASNumber: 13335},
+		{StartIP: netip.MustParseAddr("1.0.1.0"), EndIP: netip.MustParseAddr("1.0.1.255"), ASNumber: 0, ASDescription: "Not routed"},
+		{StartIP: netip.MustParseAddr("1.0.0.0"), EndIP: netip.MustParseAddr("1.0.255.255"), ASNumber: 64512},
+		{StartIP: netip.MustParseAddr("1.0.2.0"), EndIP: netip.MustParseAddr("1.0.2.255"), ASNumber: 38803},
+	}
+
+	got := PublicOnly(zones)
+	if want := []AS{zones[0], zones[3]}; !reflect.DeepEqual(got, want) {
+		t.Errorf("PublicOnly() = %v, want %v", got, want)
+	}
+	if zones[1].IsPublic() || !zones[0].IsPublic() {
+		t.Errorf("AS.IsPublic() flags the wrong zones")
+	}
+
+	r := NewASListWithOptions(zones, ListOptions{PublicOnly: true, Policy: PolicyFirstLoaded})
+	if r.IndexLen() != 2 {
+		t.Errorf("ASList.IndexLen() = %v, want 2", r.IndexLen())
+	}
+	for ip, want := range map[string]ASN{"1.0.0.1": 13335, "1.0.2.1": 38803} {
+		if as, found := r.Find(netip.MustParseAddr(ip)); !found || as.ASNumber != want {
+			t.Errorf("ASList.Find(%v) = %v, %v, want %v", ip, as, found, want)
+		}
+	}
+	if as, found := r.Find(netip.MustParseAddr("1.0.1.1")); found {
+		t.Errorf("ASList.Find(1.0.1.1) = %v, want not found", as)
+	}
+
+	if got, want := NewASNMap(zones).NonPublicASN(), []ASN{0, 64512}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ASNMap.NonPublicASN() = %v, want %v", got, want)
+	}
+}
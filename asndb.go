@@ -1,7 +1,7 @@
 // Package asndb implement asn lookup and data handling.
 // This library will handle tsv data sourced from https://iptoasn.com.
 //
-// The data can be downloaded via DownloadFromURL(DownloadViaIpToAsn), which then parsed using LoadFromTSV(),
+// The data can be downloaded via Downloader.Download(ctx, DownloadViaIpToAsn), which then parsed using LoadFromTSV(),
 // that can be used to initiate a ASList or ASNMap.
 package asndb
 
@@ -15,7 +15,7 @@ import (
 type AS struct {
 	StartIP       netip.Addr
 	EndIP         netip.Addr
-	ASNumber      int
+	ASNumber      ASN
 	CountryCode   string
 	ASDescription string
 }
@@ -26,7 +26,7 @@ func (a AS) String() string {
 	if a.StartIP.IsValid() && a.EndIP.IsValid() {
 		ip = fmt.Sprintf("[%s->%s]", a.StartIP, a.EndIP)
 	}
-	return fmt.Sprintf("AS%d(%s)@%s%s", a.ASNumber, a.ASDescription, a.CountryCode, ip)
+	return fmt.Sprintf("%s(%s)@%s%s", a.ASNumber, a.ASDescription, a.CountryCode, ip)
 }
 
 // Contains checks if an ip is part of this AS zone.
@@ -34,6 +34,16 @@ func (a AS) Contains(ip netip.Addr) bool {
 	return ip.Compare(a.StartIP) >= 0 && ip.Compare(a.EndIP) <= 0
 }
 
+// Prefixes returns the minimal list of prefixes covering this AS zone.
+// Returns nil if StartIP and EndIP do not form a valid range.
+func (a AS) Prefixes() []netip.Prefix {
+	r, ok := newKeyRange(a.StartIP.WithZone(""), a.EndIP.WithZone(""))
+	if !ok {
+		return nil
+	}
+	return r.prefixes(a.StartIP.BitLen())
+}
+
 type asSortIP []AS
 
 func (a asSortIP) Len() int {
@@ -0,0 +1,225 @@
+package asndb
+
+import (
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// FetchStatus describes where the data returned by CachedDownloader.Fetch came from.
+type FetchStatus int
+
+const (
+	// FetchModified means a new payload has been downloaded and cached.
+	FetchModified FetchStatus = iota
+	// FetchNotModified means the server reported the cached copy to still be current.
+	FetchNotModified
+	// FetchFallback means the download failed and the last good cached copy is returned instead.
+	FetchFallback
+)
+
+func (s FetchStatus) String() string {
+	switch s {
+	case FetchModified:
+		return "modified"
+	case FetchNotModified:
+		return "not modified"
+	case FetchFallback:
+		return "fallback"
+	}
+	return "unknown"
+}
+
+// FetchResult is the decompressed cached payload returned by CachedDownloader.Fetch.
+// It must be closed after use.
+type FetchResult struct {
+	io.ReadCloser
+	Status FetchStatus
+	// Fetched is when the cached copy got downloaded.
+	Fetched time.Time
+	// Err is the download error which caused a FetchFallback.
+	Err error
+}
+
+// CachedDownloader downloads data sets into a cache directory,
+// using conditional requests to avoid downloading unchanged data.
+type CachedDownloader struct {
+	// Downloader is used for requests, the zero Downloader is used when nil.
+	Downloader *Downloader
+	// Dir is the cache directory, it will be created when missing.
+	Dir string
+}
+
+// NewCachedDownloader creates a new CachedDownloader caching into dir.
+func NewCachedDownloader(d *Downloader, dir string) *CachedDownloader {
+	return &CachedDownloader{Downloader: d, Dir: dir}
+}
+
+type cacheMeta struct {
+	URL          string    `json:"url"`
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"last_modified,omitempty"`
+	Fetched      time.Time `json:"fetched"`
+}
+
+// Fetch fetches the gzip payload at url, sending If-None-Match and If-Modified-Since when a cached copy exists.
+// Only validated payloads replace the cached copy.
+// When the download fails for any reason other than ctx being done and a cached copy exists,
+// the cached copy is returned with FetchFallback and the error in FetchResult.Err.
+// Callers that already loaded the data can skip parsing when the status is not FetchModified.
+func (c *CachedDownloader) Fetch(ctx context.Context, url string) (*FetchResult, error) {
+	if err := os.MkdirAll(c.Dir, 0o755); err != nil {
+		return nil, err
+	}
+	dataPath, metaPath := c.paths(url)
+	meta, cached := readCacheMeta(dataPath, metaPath, url)
+
+	header := http.Header{}
+	if cached {
+		if meta.ETag != "" {
+			header.Set("If-None-Match", meta.ETag)
+		}
+		if meta.LastModified != "" {
+			header.Set("If-Modified-Since", meta.LastModified)
+		}
+	}
+
+	d := c.downloader()
+	var status FetchStatus
+	err := d.retry(ctx, url, func() error {
+		var err error
+		status, meta, err = c.fetch(ctx, d, url, header, dataPath, metaPath, meta)
+		return err
+	})
+	if err != nil {
+		if !cached || ctx.Err() != nil {
+			return nil, err
+		}
+		status = FetchFallback
+	}
+
+	f, openErr := os.Open(dataPath)
+	if openErr != nil {
+		return nil, openErr
+	}
+	gzipReader, openErr := gzip.NewReader(f)
+	if openErr != nil {
+		f.Close()
+		return nil, openErr
+	}
+	return &FetchResult{
+		ReadCloser: &gzipBody{url: url, gzip: gzipReader, body: f},
+		Status:     status,
+		Fetched:    meta.Fetched,
+		Err:        err,
+	}, nil
+}
+
+func (c *CachedDownloader) downloader() *Downloader {
+	if c.Downloader == nil {
+		return &Downloader{}
+	}
+	return c.Downloader
+}
+
+// paths returns the payload and metadata file paths of url.
+func (c *CachedDownloader) paths(url string) (string, string) {
+	sum := sha256.Sum256([]byte(url))
+	name := hex.EncodeToString(sum[:8])
+	return filepath.Join(c.Dir, name+".gz"), filepath.Join(c.Dir, name+".json")
+}
+
+// fetch issues a single conditional request, storing the payload when it has been modified.
+func (c *CachedDownloader) fetch(ctx context.Context, d *Downloader, url string, header http.Header,
+	dataPath, metaPath string, meta cacheMeta) (FetchStatus, cacheMeta, error) {
+	rs, err := d.get(ctx, url, header)
+	if err != nil {
+		return 0, meta, err
+	}
+	defer rs.Body.Close()
+	if rs.StatusCode == http.StatusNotModified {
+		return FetchNotModified, meta, nil
+	}
+	if err := d.checkContentType(rs.Header.Get("Content-Type")); err != nil {
+		return 0, meta, &PayloadError{URL: url, Err: err}
+	}
+
+	tmp, err := os.CreateTemp(c.Dir, ".fetch-*")
+	if err != nil {
+		return 0, meta, err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	if _, err := io.Copy(tmp, rs.Body); err != nil {
+		return 0, meta, &NetworkError{URL: url, Err: err}
+	}
+	if err := verifyGzip(url, tmp); err != nil {
+		return 0, meta, err
+	}
+	if err := tmp.Close(); err != nil {
+		return 0, meta, err
+	}
+	if err := os.Rename(tmp.Name(), dataPath); err != nil {
+		return 0, meta, err
+	}
+
+	meta = cacheMeta{
+		URL:          url,
+		ETag:         rs.Header.Get("ETag"),
+		LastModified: rs.Header.Get("Last-Modified"),
+		Fetched:      time.Now(),
+	}
+	if err := writeCacheMeta(metaPath, meta); err != nil {
+		return 0, meta, err
+	}
+	return FetchModified, meta, nil
+}
+
+// verifyGzip checks that f contains a complete gzip payload.
+func verifyGzip(url string, f *os.File) error {
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	gzipReader, err := gzip.NewReader(f)
+	if err != nil {
+		return &PayloadError{URL: url, Err: err}
+	}
+	if _, err := io.Copy(io.Discard, gzipReader); err != nil {
+		return &PayloadError{URL: url, Err: err}
+	}
+	return nil
+}
+
+// readCacheMeta reads the metadata of a cached copy, reporting false if there is no usable cached copy.
+func readCacheMeta(dataPath, metaPath, url string) (cacheMeta, bool) {
+	if _, err := os.Stat(dataPath); err != nil {
+		return cacheMeta{}, false
+	}
+	var meta cacheMeta
+	b, err := os.ReadFile(metaPath)
+	if err != nil || json.Unmarshal(b, &meta) != nil || meta.URL != url {
+		//the payload is still usable as a fallback, but can't be used for conditional requests
+		return cacheMeta{}, true
+	}
+	return meta, true
+}
+
+func writeCacheMeta(metaPath string, meta cacheMeta) error {
+	b, err := json.Marshal(meta)
+	if err != nil {
+		return err
+	}
+	tmp := metaPath + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, metaPath)
+}
@@ -0,0 +1,93 @@
+package asndb
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCachedDownloader_Fetch(t *testing.T) {
+	payload := gzipBytes(t, testTSV)
+	etag := `"v1"`
+	var gotIfNoneMatch string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotIfNoneMatch = r.Header.Get("If-None-Match")
+		switch {
+		case etag == "":
+			w.WriteHeader(http.StatusInternalServerError)
+		case gotIfNoneMatch == etag:
+			w.WriteHeader(http.StatusNotModified)
+		default:
+			w.Header().Set("ETag", etag)
+			w.Header().Set("Last-Modified", "Mon, 02 Jan 2006 15:04:05 GMT")
+			w.Write(payload)
+		}
+	}))
+	defer srv.Close()
+
+	c := NewCachedDownloader(&Downloader{Client: srv.Client()}, t.TempDir())
+	fetch := func(wantStatus FetchStatus, wantIfNoneMatch string) *FetchResult {
+		t.Helper()
+		res, err := c.Fetch(context.Background(), srv.URL)
+		if err != nil {
+			t.Fatalf("CachedDownloader.Fetch() error = %v", err)
+		}
+		defer res.Close()
+		if res.Status != wantStatus {
+			t.Errorf("CachedDownloader.Fetch() status = %v, want %v", res.Status, wantStatus)
+		}
+		if gotIfNoneMatch != wantIfNoneMatch {
+			t.Errorf("CachedDownloader.Fetch() If-None-Match = %q, want %q", gotIfNoneMatch, wantIfNoneMatch)
+		}
+		if res.Fetched.IsZero() {
+			t.Errorf("CachedDownloader.Fetch() Fetched is zero")
+		}
+		data, err := io.ReadAll(res)
+		if err != nil || string(data) != testTSV {
+			t.Errorf("CachedDownloader.Fetch() data = %q, %v, want %q", data, err, testTSV)
+		}
+		return res
+	}
+
+	t.Run("Empty Cache", func(t *testing.T) {
+		fetch(FetchModified, "")
+	})
+	t.Run("Not Modified", func(t *testing.T) {
+		fetch(FetchNotModified, `"v1"`)
+	})
+	t.Run("Modified", func(t *testing.T) {
+		etag = `"v2"`
+		fetch(FetchModified, `"v1"`)
+		fetch(FetchNotModified, `"v2"`)
+	})
+	t.Run("Corrupt Payload", func(t *testing.T) {
+		etag = `"v3"`
+		payload = []byte("<html></html>")
+		res := fetch(FetchFallback, `"v2"`)
+		var payloadErr *PayloadError
+		if !errors.As(res.Err, &payloadErr) {
+			t.Errorf("FetchResult.Err = %v, want *PayloadError", res.Err)
+		}
+		//the corrupt payload must not have replaced the etag of the last good copy
+		fetch(FetchFallback, `"v2"`)
+	})
+	t.Run("Server Error", func(t *testing.T) {
+		etag = ""
+		res := fetch(FetchFallback, `"v2"`)
+		var statusErr *StatusError
+		if !errors.As(res.Err, &statusErr) {
+			t.Errorf("FetchResult.Err = %v, want *StatusError", res.Err)
+		}
+	})
+	t.Run("Server Error Without Cache", func(t *testing.T) {
+		c := NewCachedDownloader(&Downloader{Client: srv.Client()}, t.TempDir())
+		res, err := c.Fetch(context.Background(), srv.URL)
+		var statusErr *StatusError
+		if res != nil || !errors.As(err, &statusErr) {
+			t.Errorf("CachedDownloader.Fetch() = %v, %v, want *StatusError", res, err)
+		}
+	})
+}
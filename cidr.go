@@ -0,0 +1,108 @@
+package asndb
+
+import (
+	"math/bits"
+	"net/netip"
+	"sort"
+)
+
+// keyRange is a range of addresses of one family as 128-bit keys, IPv4 addresses only use the low 32 bits.
+type keyRange struct {
+	start, end uint128
+}
+
+// newKeyRange converts start and end into a keyRange, reporting false if they do not form a valid range.
+func newKeyRange(start, end netip.Addr) (keyRange, bool) {
+	if !start.IsValid() || checkRange(start, end) != nil {
+		return keyRange{}, false
+	}
+	if start.Is4() {
+		return keyRange{start: uint128{lo: uint64(key4(start))}, end: uint128{lo: uint64(key4(end))}}, true
+	}
+	return keyRange{start: key6(start), end: key6(end)}, true
+}
+
+// prefixes returns the minimal list of prefixes covering the range, bitLen is the address length of its family.
+func (r keyRange) prefixes(bitLen int) []netip.Prefix {
+	var s []netip.Prefix
+	start := r.start
+	for {
+		//the largest block aligned on start, that does not go past end
+		k := start.trailingZeros()
+		if k > bitLen {
+			k = bitLen
+		}
+		last := start.or(hostMask6(128 - k))
+		for r.end.less(last) {
+			k--
+			last = start.or(hostMask6(128 - k))
+		}
+
+		var addr netip.Addr
+		if bitLen == 32 {
+			addr = addr4(uint32(start.lo))
+		} else {
+			addr = start.addr()
+		}
+		s = append(s, netip.PrefixFrom(addr, bitLen-k))
+
+		if last == r.end {
+			return s
+		}
+		start = last.addOne()
+	}
+}
+
+// mergeKeyRanges sorts the ranges and merges all overlapping and adjacent ranges.
+func mergeKeyRanges(rs []keyRange) []keyRange {
+	if len(rs) == 0 {
+		return nil
+	}
+	sort.Slice(rs, func(i, j int) bool {
+		return rs[i].start.less(rs[j].start)
+	})
+	merged := []keyRange{rs[0]}
+	for _, r := range rs[1:] {
+		last := &merged[len(merged)-1]
+		if r.start.less(last.end) || r.start == last.end || r.start == last.end.addOne() {
+			if last.end.less(r.end) {
+				last.end = r.end
+			}
+			continue
+		}
+		merged = append(merged, r)
+	}
+	return merged
+}
+
+// rangeGroup collects the ranges of a group of zones per family.
+type rangeGroup struct {
+	v4, v6 []keyRange
+}
+
+func (g *rangeGroup) add(as AS) {
+	r, ok := newKeyRange(as.StartIP.WithZone(""), as.EndIP.WithZone(""))
+	switch {
+	case !ok:
+	case as.StartIP.Is4():
+		g.v4 = append(g.v4, r)
+	default:
+		g.v6 = append(g.v6, r)
+	}
+}
+
+func (u uint128) trailingZeros() int {
+	if u.lo != 0 {
+		return bits.TrailingZeros64(u.lo)
+	}
+	return 64 + bits.TrailingZeros64(u.hi)
+}
+
+func (u uint128) addOne() uint128 {
+	lo := u.lo + 1
+	hi := u.hi
+	if lo == 0 {
+		hi++
+	}
+	return uint128{hi: hi, lo: lo}
+}
@@ -0,0 +1,72 @@
+package asndb
+
+import (
+	"math/rand"
+	"net/netip"
+	"reflect"
+	"testing"
+)
+
+func TestAS_Prefixes(t *testing.T) {
+	tests := []struct {
+		start string
+		end   string
+		want  []string
+	}{
+		{start: "1.0.0.0", end: "1.0.0.255", want: []string{"1.0.0.0/24"}},
+		{start: "1.0.1.0", end: "1.0.3.255", want: []string{"1.0.1.0/24", "1.0.2.0/23"}},
+		{start: "1.0.0.1", end: "1.0.0.6", want: []string{"1.0.0.1/32", "1.0.0.2/31", "1.0.0.4/31", "1.0.0.6/32"}},
+		{start: "10.0.0.5", end: "10.0.0.5", want: []string{"10.0.0.5/32"}},
+		{start: "0.0.0.0", end: "255.255.255.255", want: []string{"0.0.0.0/0"}},
+		{start: "255.255.255.254", end: "255.255.255.255", want: []string{"255.255.255.254/31"}},
+		{start: "2001:db8::", end: "2001:db8:ffff:ffff:ffff:ffff:ffff:ffff", want: []string{"2001:db8::/32"}},
+		{start: "2001:db8::1", end: "2001:db8::3", want: []string{"2001:db8::1/128", "2001:db8::2/127"}},
+		{start: "::", end: "ffff:ffff:ffff:ffff:ffff:ffff:ffff:ffff", want: []string{"::/0"}},
+		{start: "8000::", end: "ffff:ffff:ffff:ffff:ffff:ffff:ffff:ffff", want: []string{"8000::/1"}},
+		{start: "1.0.0.1", end: "1.0.0.0"},
+		{start: "1.0.0.1", end: "::1"},
+	}
+	for _, tt := range tests {
+		as := AS{StartIP: netip.MustParseAddr(tt.start), EndIP: netip.MustParseAddr(tt.end)}
+		var got []string
+		for _, p := range as.Prefixes() {
+			got = append(got, p.String())
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("AS{%v->%v}.Prefixes() = %v, want %v", tt.start, tt.end, got, tt.want)
+		}
+	}
+
+	if got := (AS{}).Prefixes(); got != nil {
+		t.Errorf("AS{}.Prefixes() = %v, want nil", got)
+	}
+
+	t.Run("Random", func(t *testing.T) {
+		rnd := rand.New(rand.NewSource(1))
+		for i := 0; i < 1000; i++ {
+			start, end := rnd.Uint32(), rnd.Uint32()
+			if start > end {
+				start, end = end, start
+			}
+			prefixes := AS{StartIP: addr4(start), EndIP: addr4(end)}.Prefixes()
+			//prefixes must be consecutive, exactly covering the range
+			next := uint64(start)
+			for _, p := range prefixes {
+				if p != p.Masked() || uint64(key4(p.Addr())) != next {
+					t.Fatalf("%v->%v: prefix %v does not start at %v", addr4(start), addr4(end), p, addr4(uint32(next)))
+				}
+				next += 1 << (32 - p.Bits())
+			}
+			if next != uint64(end)+1 {
+				t.Fatalf("%v->%v: prefixes %v end before %v", addr4(start), addr4(end), prefixes, addr4(end))
+			}
+			//a minimal list never has more than 2 prefixes of the same length
+			count := make(map[int]int)
+			for _, p := range prefixes {
+				if count[p.Bits()]++; count[p.Bits()] > 2 {
+					t.Fatalf("%v->%v: prefixes %v are not minimal", addr4(start), addr4(end), prefixes)
+				}
+			}
+		}
+	})
+}
@@ -0,0 +1,98 @@
+package asndb
+
+import (
+	"fmt"
+	"io"
+	"net/netip"
+	"sort"
+	"strings"
+)
+
+// CountryZone is a range of addresses registered to a country, as listed by the ip2country data sets.
+type CountryZone struct {
+	StartIP     netip.Addr
+	EndIP       netip.Addr
+	CountryCode string
+}
+
+// LoadCountriesFromTSV parses the ip2country tsv data from iptoasn in strict mode.
+// See LoadCountriesFromTSVWithOptions.
+func LoadCountriesFromTSV(reader io.Reader) ([]CountryZone, error) {
+	return LoadCountriesFromTSVWithOptions(reader, LoadOptions{})
+}
+
+// LoadCountriesFromTSVWithOptions parses the ip2country tsv data from iptoasn, which has a start, end and country column.
+// Like LoadFromTSVWithOptions, both text and u32 addresses are supported, and rejected rows are handled according to opts.
+func LoadCountriesFromTSVWithOptions(reader io.Reader, opts LoadOptions) ([]CountryZone, error) {
+	var s []CountryZone
+	format := opts.Format
+	err := scanRows(reader, opts, func(line string) error {
+		if format == TSVAuto {
+			format = detectTSVFormat(line)
+		}
+		z, err := parseCountryRow(line, format)
+		if err != nil {
+			return err
+		}
+		s = append(s, z)
+		return nil
+	})
+	return s, err
+}
+
+func parseCountryRow(line string, format TSVFormat) (CountryZone, error) {
+	parts := strings.Split(line, "\t")
+	if len(parts) < 3 {
+		return CountryZone{}, fmt.Errorf(`%w: want 3 parts got %d`, ErrMalformedRow, len(parts))
+	}
+
+	var start, end netip.Addr
+	var err error
+	if format == TSVU32 {
+		start, end, err = parseU32Range(parts[0], parts[1])
+	} else {
+		start, end, err = parseRange(parts[0], parts[1])
+	}
+	if err != nil {
+		return CountryZone{}, err
+	}
+	return CountryZone{StartIP: start, EndIP: end, CountryCode: parts[2]}, nil
+}
+
+// CountryList looks up the country of IP addresses.
+// It uses the same storage and search as ASList, without keeping any ASN or description.
+type CountryList struct {
+	list *ASList
+}
+
+// NewCountryList creates a new CountryList from the given list of country zones.
+// Like NewASList, the zones are sorted by StartIP, and the closest zone is returned when zones overlap.
+func NewCountryList(s []CountryZone) *CountryList {
+	zones := make([]AS, len(s))
+	for i, z := range s {
+		zones[i] = AS{StartIP: z.StartIP, EndIP: z.EndIP, CountryCode: z.CountryCode}
+	}
+	sort.Sort(asSortIP(zones))
+	return &CountryList{list: newSortedASList(zones)}
+}
+
+// Find finds and returns the country zone for a given IP address.
+// Bool indicates if the zone is valid and found.
+func (c *CountryList) Find(ip netip.Addr) (CountryZone, bool) {
+	as, found := c.list.Find(ip)
+	if !found {
+		return CountryZone{}, false
+	}
+	return CountryZone{StartIP: as.StartIP, EndIP: as.EndIP, CountryCode: as.CountryCode}, true
+}
+
+// Country returns the country code of a given IP address.
+func (c *CountryList) Country(ip netip.Addr) (string, bool) {
+	z, found := c.Find(ip)
+	return z.CountryCode, found
+}
+
+// IndexLen returns the number of country zones.
+func (c *CountryList) IndexLen() int {
+	return c.list.IndexLen()
+}
@@ -0,0 +1,89 @@
+package asndb
+
+import (
+	"errors"
+	"net/netip"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoadCountriesFromTSV(t *testing.T) {
+	text := "1.0.0.0\t1.0.0.255\tUS\n1.0.1.0\t1.0.3.255\tCN\n2001:200::\t2001:200:5ff:ffff:ffff:ffff:ffff:ffff\tJP\n"
+	u32 := "16777216\t16777471\tUS\n16777472\t16778239\tCN\n"
+
+	got, err := LoadCountriesFromTSV(strings.NewReader(text))
+	if err != nil {
+		t.Fatalf("LoadCountriesFromTSV() error = %v", err)
+	}
+	want := []CountryZone{
+		{StartIP: netip.MustParseAddr("1.0.0.0"), EndIP: netip.MustParseAddr("1.0.0.255"), CountryCode: "US"},
+		{StartIP: netip.MustParseAddr("1.0.1.0"), EndIP: netip.MustParseAddr("1.0.3.255"), CountryCode: "CN"},
+		{StartIP: netip.MustParseAddr("2001:200::"), EndIP: netip.MustParseAddr("2001:200:5ff:ffff:ffff:ffff:ffff:ffff"), CountryCode: "JP"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("LoadCountriesFromTSV() = %v, want %v", got, want)
+	}
+
+	got, err = LoadCountriesFromTSV(strings.NewReader(u32))
+	if err != nil || !reflect.DeepEqual(got, want[:2]) {
+		t.Errorf("LoadCountriesFromTSV(u32) = %v, %v, want %v", got, err, want[:2])
+	}
+
+	bad := []struct {
+		name string
+		data string
+		want error
+	}{
+		{name: "columns", data: "1.0.0.0\t1.0.0.255", want: ErrMalformedRow},
+		{name: "address", data: "1.0.0.0\tfoo\tUS", want: ErrInvalidAddress},
+		{name: "inverted", data: "1.0.0.255\t1.0.0.0\tUS", want: ErrInvertedRange},
+	}
+	for _, tt := range bad {
+		var rowErr *RowError
+		if _, err := LoadCountriesFromTSV(strings.NewReader(tt.data)); !errors.As(err, &rowErr) || !errors.Is(err, tt.want) {
+			t.Errorf("LoadCountriesFromTSV(%s) error = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+
+	s, err := LoadCountriesFromTSVWithOptions(strings.NewReader(text+"1.0.0.0\tfoo\tUS\n"), LoadOptions{Mode: LoadLenient})
+	var loadErr *LoadError
+	if !errors.As(err, &loadErr) || len(loadErr.Rows) != 1 || len(s) != 3 {
+		t.Errorf("LoadCountriesFromTSVWithOptions() lenient = %v, %v", s, err)
+	}
+}
+
+func TestCountryList(t *testing.T) {
+	c := NewCountryList([]CountryZone{
+		{StartIP: netip.MustParseAddr("2001:200::"), EndIP: netip.MustParseAddr("2001:200:5ff:ffff:ffff:ffff:ffff:ffff"), CountryCode: "JP"},
+		{StartIP: netip.MustParseAddr("1.0.1.0"), EndIP: netip.MustParseAddr("1.0.3.255"), CountryCode: "CN"},
+		{StartIP: netip.MustParseAddr("1.0.0.0"), EndIP: netip.MustParseAddr("1.0.0.255"), CountryCode: "US"},
+	})
+	if c.IndexLen() != 3 {
+		t.Errorf("CountryList.IndexLen() = %v, want 3", c.IndexLen())
+	}
+	tests := []struct {
+		ip        string
+		want      string
+		wantFound bool
+	}{
+		{ip: "0.255.255.255"},
+		{ip: "1.0.0.0", want: "US", wantFound: true},
+		{ip: "1.0.2.1", want: "CN", wantFound: true},
+		{ip: "1.0.4.0"},
+		{ip: "2001:200::1", want: "JP", wantFound: true},
+		{ip: "2001:201::"},
+	}
+	for _, tt := range tests {
+		got, found := c.Country(netip.MustParseAddr(tt.ip))
+		if got != tt.want || found != tt.wantFound {
+			t.Errorf("CountryList.Country(%v) = %v, %v, want %v, %v", tt.ip, got, found, tt.want, tt.wantFound)
+		}
+	}
+
+	z, found := c.Find(netip.MustParseAddr("1.0.2.1"))
+	want := CountryZone{StartIP: netip.MustParseAddr("1.0.1.0"), EndIP: netip.MustParseAddr("1.0.3.255"), CountryCode: "CN"}
+	if !found || z != want {
+		t.Errorf("CountryList.Find() = %v, %v, want %v", z, found, want)
+	}
+}
@@ -0,0 +1,234 @@
+package asndb
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/netip"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Delegation statuses as used in RIR statistics files.
+const (
+	StatusAllocated = "allocated"
+	StatusAssigned  = "assigned"
+	StatusAvailable = "available"
+	StatusReserved  = "reserved"
+)
+
+// Delegation holds the registration details shared by every record of a RIR statistics file.
+type Delegation struct {
+	Registry RIR
+	// CountryCode is the ISO 3166 code of the holder, empty or ZZ for available and reserved resources.
+	CountryCode string
+	// Date is when the resource got allocated or assigned, zero when unknown.
+	Date time.Time
+	// Status is one of the Status* values.
+	Status string
+	// OpaqueID identifies the holder, all resources sharing an OpaqueID belong to the same holder.
+	// It is empty for files not in the extended format.
+	OpaqueID string
+}
+
+// ASNDelegation is a block of ASN from a RIR statistics file, covering First to Last inclusive.
+type ASNDelegation struct {
+	First, Last ASN
+	Delegation
+}
+
+// IPDelegation is a block of addresses from a RIR statistics file.
+type IPDelegation struct {
+	StartIP, EndIP netip.Addr
+	Delegation
+}
+
+// DelegatedStats is the content of one or more RIR statistics files.
+type DelegatedStats struct {
+	// ASN is sorted by First.
+	ASN []ASNDelegation
+	// IP is sorted by StartIP.
+	IP []IPDelegation
+}
+
+// LoadDelegatedStats parses a RIR statistics file, such as delegated-ripencc-extended-latest.
+// Both the extended format and the older format without opaque-id are accepted,
+// the version, summary and comment lines are skipped.
+// Malformed rows are handled according to opts like LoadFromTSVWithOptions.
+func LoadDelegatedStats(reader io.Reader, opts LoadOptions) (*DelegatedStats, error) {
+	d := &DelegatedStats{}
+	err := scanRows(reader, opts, func(line string) error {
+		return d.parseRow(line)
+	})
+	d.sort()
+	return d, err
+}
+
+func (d *DelegatedStats) parseRow(line string) error {
+	if line == "" || line[0] == '#' {
+		return nil
+	}
+	parts := strings.Split(line, "|")
+	//the version line starts with the format version, while records start with the registry
+	if line[0] >= '0' && line[0] <= '9' {
+		return nil
+	}
+	if len(parts) >= 6 && parts[5] == "summary" {
+		return nil
+	}
+	if len(parts) < 7 {
+		return fmt.Errorf("%w: want 7 parts got %d", ErrMalformedRow, len(parts))
+	}
+
+	registry, err := parseRegistry(parts[0])
+	if err != nil {
+		return err
+	}
+	date, err := parseDelegationDate(parts[5])
+	if err != nil {
+		return err
+	}
+	dl := Delegation{
+		Registry:    registry,
+		CountryCode: parts[1],
+		Date:        date,
+		Status:      parts[6],
+	}
+	if len(parts) >= 8 {
+		dl.OpaqueID = parts[7]
+	}
+
+	switch parts[2] {
+	case "asn":
+		first, err := ParseASN(parts[3])
+		if err != nil {
+			return err
+		}
+		count, err := strconv.ParseUint(parts[4], 10, 32)
+		if err != nil || count == 0 || uint64(first)+count-1 > math.MaxUint32 {
+			return fmt.Errorf("%w: asn count %q", ErrMalformedRow, parts[4])
+		}
+		d.ASN = append(d.ASN, ASNDelegation{First: first, Last: first + ASN(count-1), Delegation: dl})
+	case "ipv4":
+		start, err := netip.ParseAddr(parts[3])
+		if err != nil || !start.Is4() {
+			return fmt.Errorf("%w: %q", ErrInvalidAddress, parts[3])
+		}
+		count, err := strconv.ParseUint(parts[4], 10, 64)
+		if err != nil || count == 0 || uint64(key4(start))+count-1 > math.MaxUint32 {
+			return fmt.Errorf("%w: ipv4 count %q", ErrMalformedRow, parts[4])
+		}
+		end := addr4(key4(start) + uint32(count-1))
+		d.IP = append(d.IP, IPDelegation{StartIP: start, EndIP: end, Delegation: dl})
+	case "ipv6":
+		start, err := netip.ParseAddr(parts[3])
+		if err != nil || !start.Is6() || start.Zone() != "" {
+			return fmt.Errorf("%w: %q", ErrInvalidAddress, parts[3])
+		}
+		bits, err := strconv.Atoi(parts[4])
+		if err != nil || bits < 0 || bits > 128 {
+			return fmt.Errorf("%w: ipv6 prefix length %q", ErrMalformedRow, parts[4])
+		}
+		start, end := prefixRange(netip.PrefixFrom(start, bits))
+		d.IP = append(d.IP, IPDelegation{StartIP: start, EndIP: end, Delegation: dl})
+	default:
+		return fmt.Errorf("%w: unknown type %q", ErrMalformedRow, parts[2])
+	}
+	return nil
+}
+
+// parseRegistry parses a registry name as used in RIR statistics files.
+func parseRegistry(s string) (RIR, error) {
+	switch s {
+	case "arin":
+		return RIRARIN, nil
+	case "ripencc":
+		return RIRRIPENCC, nil
+	case "apnic":
+		return RIRAPNIC, nil
+	case "lacnic":
+		return RIRLACNIC, nil
+	case "afrinic":
+		return RIRAFRINIC, nil
+	case "iana":
+		return RIRUnknown, nil
+	}
+	return RIRUnknown, fmt.Errorf("%w: unknown registry %q", ErrMalformedRow, s)
+}
+
+// parseDelegationDate parses a YYYYMMDD date, which is empty or all zeros when unknown.
+func parseDelegationDate(s string) (time.Time, error) {
+	if s == "" || s == "00000000" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse("20060102", s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: date %q", ErrMalformedRow, s)
+	}
+	return t, nil
+}
+
+func (d *DelegatedStats) sort() {
+	sort.SliceStable(d.ASN, func(i, j int) bool {
+		return d.ASN[i].First < d.ASN[j].First
+	})
+	sort.SliceStable(d.IP, func(i, j int) bool {
+		return d.IP[i].StartIP.Less(d.IP[j].StartIP)
+	})
+}
+
+// Merge adds the records of other, such as the statistics file of another RIR.
+func (d *DelegatedStats) Merge(other *DelegatedStats) {
+	d.ASN = append(d.ASN, other.ASN...)
+	d.IP = append(d.IP, other.IP...)
+	d.sort()
+}
+
+// FindASN returns the registration of asn.
+// Bool is false if asn is not listed in any file.
+func (d *DelegatedStats) FindASN(asn ASN) (ASNDelegation, bool) {
+	//ASN blocks never overlap, so the block starting last at or before asn is the only candidate
+	i := sort.Search(len(d.ASN), func(i int) bool {
+		return d.ASN[i].First > asn
+	}) - 1
+	if i >= 0 && d.ASN[i].Last >= asn {
+		return d.ASN[i], true
+	}
+	return ASNDelegation{}, false
+}
+
+// Zones returns the allocated and assigned address blocks as AS zones, to be looked up with an ASList.
+// As statistics files do not list routing, zones have ASNumber 0 and the name of the registry as ASDescription.
+func (d *DelegatedStats) Zones() []AS {
+	var s []AS
+	for _, ip := range d.IP {
+		if ip.Status != StatusAllocated && ip.Status != StatusAssigned {
+			continue
+		}
+		s = append(s, AS{
+			StartIP:       ip.StartIP,
+			EndIP:         ip.EndIP,
+			CountryCode:   ip.CountryCode,
+			ASDescription: ip.Registry.String(),
+		})
+	}
+	return s
+}
+
+// FillCountryCodes sets the CountryCode of zones whose country is unknown, "None" or empty,
+// to the country their ASN is registered to. It returns the number of zones updated.
+func (d *DelegatedStats) FillCountryCodes(s []AS) int {
+	var n int
+	for i, as := range s {
+		if as.CountryCode != "" && as.CountryCode != "None" {
+			continue
+		}
+		if reg, ok := d.FindASN(as.ASNumber); ok && reg.CountryCode != "" && reg.CountryCode != "ZZ" {
+			s[i].CountryCode = reg.CountryCode
+			n++
+		}
+	}
+	return n
+}
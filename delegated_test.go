@@ -0,0 +1,148 @@
+package asndb
+
+import (
+	"errors"
+	"net/netip"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testDelegated = `# comment
+2.3|ripencc|1718143199|3|19830705|20240611|+0200
+ripencc|*|ipv4|*|2|summary
+ripencc|*|asn|*|2|summary
+ripencc|*|ipv6|*|1|summary
+ripencc|EU|asn|1877|1|19930901|allocated|9a4d1c4e-0d47-4f32-a27f-0d6a0a6b1c2d
+ripencc|DE|asn|24940|1|20020729|allocated|4b2b9e3c-8f2e-4a3b-9e5e-2e64b2c0c3a1
+ripencc|ZZ|asn|64396|100||reserved|
+ripencc|DE|ipv4|5.9.0.0|65536|20100315|allocated|4b2b9e3c-8f2e-4a3b-9e5e-2e64b2c0c3a1
+ripencc|GB|ipv4|2.16.0.0|768|20100712|allocated|6c1b58d3-8d47-4bf5-9d3c-5d5d9e6d4c1f
+ripencc|DE|ipv6|2a01:4f8::|29|20050829|allocated|4b2b9e3c-8f2e-4a3b-9e5e-2e64b2c0c3a1
+ripencc||ipv4|2.56.0.0|1024||available
+`
+
+func TestLoadDelegatedStats(t *testing.T) {
+	d, err := LoadDelegatedStats(strings.NewReader(testDelegated), LoadOptions{})
+	if err != nil {
+		t.Fatalf("LoadDelegatedStats() error = %v", err)
+	}
+
+	hetzner := Delegation{
+		Registry:    RIRRIPENCC,
+		CountryCode: "DE",
+		Date:        time.Date(2002, 7, 29, 0, 0, 0, 0, time.UTC),
+		Status:      StatusAllocated,
+		OpaqueID:    "4b2b9e3c-8f2e-4a3b-9e5e-2e64b2c0c3a1",
+	}
+	wantASN := []ASNDelegation{
+		{First: 1877, Last: 1877, Delegation: Delegation{Registry: RIRRIPENCC, CountryCode: "EU",
+			Date: time.Date(1993, 9, 1, 0, 0, 0, 0, time.UTC), Status: StatusAllocated, OpaqueID: "9a4d1c4e-0d47-4f32-a27f-0d6a0a6b1c2d"}},
+		{First: 24940, Last: 24940, Delegation: hetzner},
+		{First: 64396, Last: 64495, Delegation: Delegation{Registry: RIRRIPENCC, CountryCode: "ZZ", Status: StatusReserved}},
+	}
+	if !reflect.DeepEqual(d.ASN, wantASN) {
+		t.Errorf("DelegatedStats.ASN = %v, want %v", d.ASN, wantASN)
+	}
+
+	wantIP := []struct {
+		start, end string
+		cc         string
+		status     string
+	}{
+		{start: "2.16.0.0", end: "2.16.2.255", cc: "GB", status: StatusAllocated},
+		{start: "2.56.0.0", end: "2.56.3.255", status: StatusAvailable},
+		{start: "5.9.0.0", end: "5.9.255.255", cc: "DE", status: StatusAllocated},
+		{start: "2a01:4f8::", end: "2a01:4ff:ffff:ffff:ffff:ffff:ffff:ffff", cc: "DE", status: StatusAllocated},
+	}
+	if len(d.IP) != len(wantIP) {
+		t.Fatalf("DelegatedStats.IP = %v, want %d records", d.IP, len(wantIP))
+	}
+	for i, w := range wantIP {
+		got := d.IP[i]
+		if got.StartIP != netip.MustParseAddr(w.start) || got.EndIP != netip.MustParseAddr(w.end) ||
+			got.CountryCode != w.cc || got.Status != w.status {
+			t.Errorf("DelegatedStats.IP[%d] = %v, want %v", i, got, w)
+		}
+	}
+
+	for asn, want := range map[ASN]bool{1877: true, 1876: false, 24940: true, 64396: true, 64450: true, 64495: true, 64496: false} {
+		if _, found := d.FindASN(asn); found != want {
+			t.Errorf("DelegatedStats.FindASN(%v) found = %v, want %v", asn, found, want)
+		}
+	}
+
+	zones := d.Zones()
+	if len(zones) != 3 {
+		t.Fatalf("DelegatedStats.Zones() = %v, want 3 zones", zones)
+	}
+	as, found := NewASList(zones).Find(netip.MustParseAddr("5.9.10.11"))
+	if !found || as.CountryCode != "DE" || as.ASDescription != "RIPE NCC" {
+		t.Errorf("ASList.Find() on DelegatedStats.Zones() = %v, %v", as, found)
+	}
+}
+
+func TestDelegatedStats_FillCountryCodes(t *testing.T) {
+	d, err := LoadDelegatedStats(strings.NewReader(testDelegated), LoadOptions{})
+	if err != nil {
+		t.Fatalf("LoadDelegatedStats() error = %v", err)
+	}
+	other, err := LoadDelegatedStats(strings.NewReader("arin|US|asn|13335|1|20100714|assigned|C02593367\n"), LoadOptions{})
+	if err != nil {
+		t.Fatalf("LoadDelegatedStats() error = %v", err)
+	}
+	d.Merge(other)
+
+	s := []AS{
+		{ASNumber: 24940, CountryCode: "None"},
+		{ASNumber: 13335, CountryCode: ""},
+		{ASNumber: 1877, CountryCode: "NL"},
+		{ASNumber: 64400, CountryCode: "None"},
+		{ASNumber: 15169, CountryCode: "None"},
+	}
+	if n := d.FillCountryCodes(s); n != 2 {
+		t.Errorf("DelegatedStats.FillCountryCodes() = %v, want 2", n)
+	}
+	var got []string
+	for _, as := range s {
+		got = append(got, as.CountryCode)
+	}
+	if want := []string{"DE", "US", "NL", "None", "None"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("DelegatedStats.FillCountryCodes() countries = %v, want %v", got, want)
+	}
+}
+
+func TestLoadDelegatedStats_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		want error
+	}{
+		{name: "short", line: "ripencc|DE|asn|1", want: ErrMalformedRow},
+		{name: "registry", line: "example|DE|asn|1|1|20000101|allocated", want: ErrMalformedRow},
+		{name: "type", line: "ripencc|DE|asn32|1|1|20000101|allocated", want: ErrMalformedRow},
+		{name: "asn", line: "ripencc|DE|asn|x|1|20000101|allocated", want: ErrInvalidASN},
+		{name: "asn count", line: "ripencc|DE|asn|4294967295|2|20000101|allocated", want: ErrMalformedRow},
+		{name: "date", line: "ripencc|DE|asn|1|1|2000-01-01|allocated", want: ErrMalformedRow},
+		{name: "ipv4", line: "ripencc|DE|ipv4|::1|256|20000101|allocated", want: ErrInvalidAddress},
+		{name: "ipv4 count", line: "ripencc|DE|ipv4|255.255.255.0|257|20000101|allocated", want: ErrMalformedRow},
+		{name: "ipv6", line: "ripencc|DE|ipv6|1.0.0.0|32|20000101|allocated", want: ErrInvalidAddress},
+		{name: "ipv6 prefix", line: "ripencc|DE|ipv6|2001:db8::|129|20000101|allocated", want: ErrMalformedRow},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadDelegatedStats(strings.NewReader(tt.line), LoadOptions{})
+			var rowErr *RowError
+			if !errors.As(err, &rowErr) || !errors.Is(err, tt.want) {
+				t.Errorf("LoadDelegatedStats() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+
+	d, err := LoadDelegatedStats(strings.NewReader(testDelegated+"ripencc|DE|asn|x|1|20000101|allocated\n"), LoadOptions{Mode: LoadLenient})
+	var loadErr *LoadError
+	if !errors.As(err, &loadErr) || len(loadErr.Rows) != 1 || len(d.ASN) != 3 {
+		t.Errorf("LoadDelegatedStats() lenient = %v, %v", d, err)
+	}
+}
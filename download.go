@@ -0,0 +1,266 @@
+package asndb
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"time"
+)
+
+const DownloadViaIpToAsn = "https://iptoasn.com/data/ip2asn-combined.tsv.gz"
+
+// URLs of the per-family data sets of iptoasn, the u32 data set has IPv4 addresses as integers.
+const (
+	DownloadViaIpToAsnV4    = "https://iptoasn.com/data/ip2asn-v4.tsv.gz"
+	DownloadViaIpToAsnV6    = "https://iptoasn.com/data/ip2asn-v6.tsv.gz"
+	DownloadViaIpToAsnV4U32 = "https://iptoasn.com/data/ip2asn-v4-u32.tsv.gz"
+)
+
+// URLs of the ip2country data sets of iptoasn, see LoadCountriesFromTSV.
+const (
+	DownloadViaIpToCountryV4 = "https://iptoasn.com/data/ip2country-v4.tsv.gz"
+	DownloadViaIpToCountryV6 = "https://iptoasn.com/data/ip2country-v6.tsv.gz"
+)
+
+// DefaultContentTypes are the content types accepted by a Downloader when none are configured.
+var DefaultContentTypes = []string{
+	"application/gzip",
+	"application/x-gzip",
+	"application/octet-stream",
+	"binary/octet-stream",
+}
+
+// NetworkError is returned when the data could not be transferred, it is usually temporary.
+type NetworkError struct {
+	URL string
+	Err error
+}
+
+func (e *NetworkError) Error() string {
+	return fmt.Sprintf("download %s: %v", e.URL, e.Err)
+}
+
+func (e *NetworkError) Unwrap() error {
+	return e.Err
+}
+
+// StatusError is returned when the server responds with a non 200 status code.
+type StatusError struct {
+	URL        string
+	StatusCode int
+	Status     string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("download %s: unexpected status %s", e.URL, e.Status)
+}
+
+// Temporary reports if the status code is worth retrying.
+func (e *StatusError) Temporary() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= 500
+}
+
+// PayloadError is returned when the server responded, but the data is not a valid gzip payload.
+type PayloadError struct {
+	URL string
+	Err error
+}
+
+func (e *PayloadError) Error() string {
+	return fmt.Sprintf("download %s: invalid payload: %v", e.URL, e.Err)
+}
+
+func (e *PayloadError) Unwrap() error {
+	return e.Err
+}
+
+// Downloader downloads and decompresses gzip data sets such as DownloadViaIpToAsn.
+// The zero value is usable and uses http.DefaultClient without retries.
+type Downloader struct {
+	// Client is the client used for requests, http.DefaultClient is used when nil.
+	Client *http.Client
+	// Retries is how many times a failed download is retried.
+	// Only network errors and temporary status codes are retried.
+	Retries int
+	// Backoff is the delay before the first retry, it doubles for every following retry.
+	Backoff time.Duration
+	// ContentTypes are the accepted content types, DefaultContentTypes is used when nil.
+	// Responses without a content type are always accepted.
+	ContentTypes []string
+}
+
+// NewDownloader creates a new Downloader using the given client, retrying 3 times starting with a 1 second backoff.
+func NewDownloader(client *http.Client) *Downloader {
+	return &Downloader{
+		Client:  client,
+		Retries: 3,
+		Backoff: time.Second,
+	}
+}
+
+// Download downloads the gzip payload at url and returns its decompressed content.
+// Closing the returned reader also closes the underlying response body.
+// Failed downloads return a *NetworkError, *StatusError or *PayloadError,
+// read errors of the returned reader are either a *NetworkError or a *PayloadError.
+func (d *Downloader) Download(ctx context.Context, url string) (io.ReadCloser, error) {
+	var r io.ReadCloser
+	err := d.retry(ctx, url, func() error {
+		var err error
+		r, err = d.download(ctx, url)
+		return err
+	})
+	return r, err
+}
+
+// retry calls fn until it succeeds, returns a non retryable error or the retries are exhausted.
+func (d *Downloader) retry(ctx context.Context, url string, fn func() error) error {
+	backoff := d.Backoff
+	for attempt := 0; ; attempt++ {
+		err := fn()
+		if err == nil || attempt >= d.Retries || !retryable(err) || ctx.Err() != nil {
+			return err
+		}
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return &NetworkError{URL: url, Err: ctx.Err()}
+		case <-timer.C:
+		}
+		backoff *= 2
+	}
+}
+
+func (d *Downloader) download(ctx context.Context, url string) (io.ReadCloser, error) {
+	rs, err := d.get(ctx, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if rs.StatusCode != http.StatusOK {
+		rs.Body.Close()
+		return nil, &StatusError{URL: url, StatusCode: rs.StatusCode, Status: rs.Status}
+	}
+	return d.decompress(url, rs)
+}
+
+// get issues a GET request with the given extra headers, validating the status code.
+// Both 200 and 304 are considered successful.
+func (d *Downloader) get(ctx context.Context, url string, header http.Header) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+
+	client := d.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	rs, err := client.Do(req)
+	if err != nil {
+		return nil, &NetworkError{URL: url, Err: err}
+	}
+	if rs.StatusCode != http.StatusOK && rs.StatusCode != http.StatusNotModified {
+		rs.Body.Close()
+		return nil, &StatusError{URL: url, StatusCode: rs.StatusCode, Status: rs.Status}
+	}
+	return rs, nil
+}
+
+// decompress validates the response's content type and wraps its body in a gzip reader.
+func (d *Downloader) decompress(url string, rs *http.Response) (io.ReadCloser, error) {
+	if err := d.checkContentType(rs.Header.Get("Content-Type")); err != nil {
+		rs.Body.Close()
+		return nil, &PayloadError{URL: url, Err: err}
+	}
+
+	gzipReader, err := gzip.NewReader(rs.Body)
+	if err == io.EOF {
+		rs.Body.Close()
+		return nil, &PayloadError{URL: url, Err: errors.New("empty body")}
+	}
+	if err != nil {
+		rs.Body.Close()
+		return nil, classifyReadError(url, err)
+	}
+	return &gzipBody{url: url, gzip: gzipReader, body: rs.Body}, nil
+}
+
+func (d *Downloader) checkContentType(contentType string) error {
+	if contentType == "" {
+		return nil
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return fmt.Errorf("content type %q: %w", contentType, err)
+	}
+	accepted := d.ContentTypes
+	if accepted == nil {
+		accepted = DefaultContentTypes
+	}
+	for _, t := range accepted {
+		if mediaType == t {
+			return nil
+		}
+	}
+	return fmt.Errorf("unexpected content type %q", mediaType)
+}
+
+func retryable(err error) bool {
+	var netErr *NetworkError
+	if errors.As(err, &netErr) {
+		return true
+	}
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.Temporary()
+	}
+	return false
+}
+
+// classifyReadError wraps errors of a gzip reader, separating corrupt data from transfer failures.
+func classifyReadError(url string, err error) error {
+	var corrupt flate.CorruptInputError
+	if errors.Is(err, gzip.ErrHeader) || errors.Is(err, gzip.ErrChecksum) || errors.As(err, &corrupt) {
+		return &PayloadError{URL: url, Err: err}
+	}
+	return &NetworkError{URL: url, Err: err}
+}
+
+// gzipBody closes the response body alongside the gzip reader.
+type gzipBody struct {
+	url  string
+	gzip *gzip.Reader
+	body io.ReadCloser
+}
+
+func (g *gzipBody) Read(p []byte) (int, error) {
+	n, err := g.gzip.Read(p)
+	if err != nil && err != io.EOF {
+		err = classifyReadError(g.url, err)
+	}
+	return n, err
+}
+
+func (g *gzipBody) Close() error {
+	gErr := g.gzip.Close()
+	if err := g.body.Close(); err != nil {
+		return err
+	}
+	return gErr
+}
+
+// DownloadFromURL downloads and decompresses the gzip payload at url.
+//
+// Deprecated: DownloadFromURL can not be cancelled, use Downloader.Download instead.
+func DownloadFromURL(url string) (io.ReadCloser, error) {
+	return (&Downloader{}).Download(context.Background(), url)
+}
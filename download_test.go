@@ -0,0 +1,179 @@
+package asndb
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const testTSV = "1.0.0.0\t1.0.0.255\t13335\tUS\tCLOUDFLARENET\n1.0.1.0\t1.0.3.255\t0\tNone\tNot routed\n"
+
+func gzipBytes(t *testing.T, data string) []byte {
+	t.Helper()
+	var b bytes.Buffer
+	w := gzip.NewWriter(&b)
+	if _, err := w.Write([]byte(data)); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return b.Bytes()
+}
+
+// trackingBody records if a response body got closed.
+type trackingBody struct {
+	io.ReadCloser
+	closed *int32
+}
+
+func (b trackingBody) Close() error {
+	atomic.StoreInt32(b.closed, 1)
+	return b.ReadCloser.Close()
+}
+
+type trackingTransport struct {
+	closed int32
+}
+
+func (tr *trackingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	rs, err := http.DefaultTransport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	rs.Body = trackingBody{ReadCloser: rs.Body, closed: &tr.closed}
+	return rs, nil
+}
+
+func TestDownloader_Download(t *testing.T) {
+	payload := gzipBytes(t, testTSV)
+
+	tests := []struct {
+		name        string
+		handler     http.HandlerFunc
+		retries     int
+		wantCalls   int32
+		wantErr     interface{}
+		wantReadErr interface{}
+	}{
+		{
+			name: "valid",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/gzip")
+				w.Write(payload)
+			},
+			wantCalls: 1,
+		}, {
+			name: "not found",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "text/html")
+				w.WriteHeader(http.StatusNotFound)
+				w.Write([]byte("<html>not found</html>"))
+			},
+			retries:   3,
+			wantCalls: 1,
+			wantErr:   new(*StatusError),
+		}, {
+			name: "server error retried",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusBadGateway)
+			},
+			retries:   2,
+			wantCalls: 3,
+			wantErr:   new(*StatusError),
+		}, {
+			name: "html payload",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "text/html; charset=utf-8")
+				w.Write([]byte("<html></html>"))
+			},
+			wantCalls: 1,
+			wantErr:   new(*PayloadError),
+		}, {
+			name: "not gzip",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(testTSV))
+			},
+			retries:   3,
+			wantCalls: 1,
+			wantErr:   new(*PayloadError),
+		}, {
+			name: "corrupt checksum",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				corrupt := append([]byte(nil), payload...)
+				corrupt[len(corrupt)-5] ^= 0xff
+				w.Write(corrupt)
+			},
+			wantCalls:   1,
+			wantReadErr: new(*PayloadError),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&calls, 1)
+				tt.handler(w, r)
+			}))
+			defer srv.Close()
+
+			tr := &trackingTransport{}
+			d := &Downloader{Client: &http.Client{Transport: tr}, Retries: tt.retries, Backoff: time.Millisecond}
+			r, err := d.Download(context.Background(), srv.URL)
+			if got := atomic.LoadInt32(&calls); got != tt.wantCalls {
+				t.Errorf("Downloader.Download() calls = %v, want %v", got, tt.wantCalls)
+			}
+			if tt.wantErr != nil {
+				if !errors.As(err, tt.wantErr) {
+					t.Errorf("Downloader.Download() error = %v, want %T", err, tt.wantErr)
+				}
+				if atomic.LoadInt32(&tr.closed) != 1 {
+					t.Errorf("Downloader.Download() response body not closed on error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Downloader.Download() error = %v", err)
+			}
+
+			data, err := io.ReadAll(r)
+			if tt.wantReadErr != nil {
+				if !errors.As(err, tt.wantReadErr) {
+					t.Errorf("Downloader.Download() read error = %v, want %T", err, tt.wantReadErr)
+				}
+			} else if err != nil || string(data) != testTSV {
+				t.Errorf("Downloader.Download() = %q, %v, want %q", data, err, testTSV)
+			}
+
+			if err := r.Close(); err != nil {
+				t.Errorf("Close() error = %v", err)
+			}
+			if atomic.LoadInt32(&tr.closed) != 1 {
+				t.Errorf("Close() did not close the response body")
+			}
+		})
+	}
+}
+
+func TestDownloader_DownloadCancel(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	d := &Downloader{Client: srv.Client(), Retries: 10, Backoff: time.Hour}
+	_, err := d.Download(ctx, srv.URL)
+	var netErr *NetworkError
+	if !errors.As(err, &netErr) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Downloader.Download() error = %v, want cancelled *NetworkError", err)
+	}
+}
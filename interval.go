@@ -0,0 +1,114 @@
+package asndb
+
+// maxEndTree is an implicit interval tree over keys sorted by start, as used by cgranges.
+// Index i is a node on level k, where k is the number of trailing 1 bits of i,
+// its children are i-2^(k-1) and i+2^(k-1), and maxEnd[i] is the largest end within its subtree.
+// Even indexes are leaves, and the root is 2^root-1.
+type maxEndTree[K any] struct {
+	maxEnd []K
+	root   int
+}
+
+// newMaxEndTree builds the tree over end, whose zones are sorted by start.
+func newMaxEndTree[K any](end []K, less func(a, b K) bool) maxEndTree[K] {
+	n := len(end)
+	if n == 0 {
+		return maxEndTree[K]{root: -1}
+	}
+	maxOf := func(a, b K) K {
+		if less(a, b) {
+			return b
+		}
+		return a
+	}
+
+	maxEnd := make([]K, n)
+	var lastI int
+	var last K
+	for i := 0; i < n; i += 2 {
+		lastI, last = i, end[i]
+		maxEnd[i] = last
+	}
+	k := 1
+	for ; 1<<k <= n; k++ {
+		x := 1 << (k - 1)
+		step := x << 2
+		for i := (x << 1) - 1; i < n; i += step {
+			//the right child may be out of bounds, in which case the last subtree stands in for it
+			right := last
+			if i+x < n {
+				right = maxEnd[i+x]
+			}
+			maxEnd[i] = maxOf(end[i], maxOf(maxEnd[i-x], right))
+		}
+		//move lastI to its parent
+		if lastI>>k&1 == 1 {
+			lastI -= x
+		} else {
+			lastI += x
+		}
+		if lastI < n && less(last, maxEnd[lastI]) {
+			last = maxEnd[lastI]
+		}
+	}
+	return maxEndTree[K]{maxEnd: maxEnd, root: k - 1}
+}
+
+// overlap calls hit with the index of every zone intersecting lo to hi inclusive, in ascending order.
+// That is every zone where start <= hi and end >= lo.
+func (t maxEndTree[K]) overlap(start, end []K, lo, hi K, less func(a, b K) bool, hit func(i int)) {
+	if t.root < 0 {
+		return
+	}
+	type frame struct {
+		x, k int
+		//visited is set once the left child has been handled
+		visited bool
+	}
+	n := len(start)
+	var stack [64]frame
+	stack[0] = frame{x: 1<<t.root - 1, k: t.root}
+	top := 1
+	for top > 0 {
+		top--
+		z := stack[top]
+		switch {
+		case z.k <= 3:
+			//small subtrees are scanned linearly
+			i0 := z.x >> z.k << z.k
+			i1 := i0 + 1<<(z.k+1) - 1
+			if i1 > n {
+				i1 = n
+			}
+			for i := i0; i < i1 && !less(hi, start[i]); i++ {
+				if !less(end[i], lo) {
+					hit(i)
+				}
+			}
+		case !z.visited:
+			z.visited = true
+			stack[top] = z
+			top++
+			//the left child may be out of bounds, it's only skipped when none of its zones can reach lo
+			y := z.x - 1<<(z.k-1)
+			if y >= n || !less(t.maxEnd[y], lo) {
+				stack[top] = frame{x: y, k: z.k - 1}
+				top++
+			}
+		case z.x < n && !less(hi, start[z.x]):
+			if !less(end[z.x], lo) {
+				hit(z.x)
+			}
+			stack[top] = frame{x: z.x + 1<<(z.k-1), k: z.k - 1}
+			top++
+		}
+	}
+}
+
+func lessUint32(a, b uint32) bool {
+	return a < b
+}
+
+func lessUint128(a, b uint128) bool {
+	return a.less(b)
+}
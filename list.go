@@ -1,30 +1,197 @@
 package asndb
 
 import (
+	"encoding/binary"
+	"math"
 	"net/netip"
 	"sort"
 )
 
 // NewASList creates a new registry from the given list of AS zones.
 // The given slice will be cloned and sorted by StartIP.
+// IPv6 zones(scopes) of addresses are not preserved.
 func NewASList(s []AS) *ASList {
 	s = clone(s)
 	sort.Sort(asSortIP(s))
-	s = s[:len(s):len(s)]
+	return newSortedASList(s)
+}
+
+// MergeASLists creates a new registry holding the AS zones of all given lists,
+// such as lists loaded separately from the IPv4 and IPv6 data sets.
+// Zones with an equal StartIP keep the order of the given lists.
+//
+// The merged list uses the Policy of the first list.
+// Under PolicyPriority every zone keeps the priority it had in its own list, zones of lists using another policy get 0.
+// Under PolicyFirstLoaded the zones of earlier lists come first, each list keeping its own load order.
+// Use MergeASListsWithOptions to pick another policy.
+func MergeASLists(lists ...*ASList) *ASList {
+	policy := PolicyClosest
+	if len(lists) > 0 {
+		policy = lists[0].policy
+	}
+
+	var n int
+	for _, l := range lists {
+		n += l.IndexLen()
+	}
+	s := make([]AS, 0, n)
+	var rank []int
+	if policy == PolicyFirstLoaded || policy == PolicyPriority {
+		rank = make([]int, 0, n)
+	}
+	for _, l := range lists {
+		base := len(s)
+		s = append(s, l.zones()...)
+		switch policy {
+		case PolicyFirstLoaded:
+			for _, pos := range l.loadOrder() {
+				rank = append(rank, -(base + pos))
+			}
+		case PolicyPriority:
+			for i := 0; i < l.IndexLen(); i++ {
+				var p int
+				if l.policy == PolicyPriority {
+					p = l.rank[i]
+				}
+				rank = append(rank, p)
+			}
+		}
+	}
+
+	var rankOf func(i int) int
+	if rank != nil {
+		rankOf = func(i int) int {
+			return rank[i]
+		}
+	}
+	return newRankedASList(s, policy, false, rankOf)
+}
+
+// MergeASListsWithOptions creates a new registry holding the AS zones of all given lists, like MergeASLists,
+// using opts instead of the policy of the first list.
+// The zones are given to opts.Priority in the order of the given lists, each list ordered by StartIP.
+func MergeASListsWithOptions(opts ListOptions, lists ...*ASList) *ASList {
+	var s []AS
+	for _, l := range lists {
+		s = append(s, l.zones()...)
+	}
+	return NewASListWithOptions(s, opts)
+}
+
+// loadOrder returns the position of every zone in the order the zones got loaded,
+// which is only known for PolicyFirstLoaded, other lists are ordered by StartIP.
+func (r *ASList) loadOrder() []int {
+	pos := make([]int, r.IndexLen())
+	index := make([]int, len(pos))
+	for i := range index {
+		index[i] = i
+	}
+	if r.policy == PolicyFirstLoaded {
+		//the rank of a zone is its negated position in the slice it got loaded from
+		sort.SliceStable(index, func(a, b int) bool {
+			return r.rank[index[a]] > r.rank[index[b]]
+		})
+	}
+	for p, i := range index {
+		pos[i] = p
+	}
+	return pos
+}
+
+// newSortedASList creates a new registry from a list of AS zones already sorted by StartIP.
+// The given slice is not retained.
+func newSortedASList(s []AS) *ASList {
+	r := &ASList{}
+	metaIndex := make(map[asMeta]uint32)
+	for i, as := range s {
+		if !as.StartIP.IsValid() {
+			r.head = append(r.head, as)
+			continue
+		}
+
+		m := asMeta{ASNumber: as.ASNumber, CountryCode: as.CountryCode, ASDescription: as.ASDescription}
+		mi, ok := metaIndex[m]
+		if !ok {
+			mi = uint32(len(r.meta))
+			metaIndex[m] = mi
+			r.meta = append(r.meta, m)
+		}
+		r.metaIndex = append(r.metaIndex, mi)
 
-	r := &ASList{s: s}
+		start, end := as.StartIP.WithZone(""), as.EndIP.WithZone("")
+		if start.Is4() != end.Is4() || !end.IsValid() {
+			//the end key is never used for these zones, see ASList.contains
+			if r.ends == nil {
+				r.ends = make(map[int]netip.Addr)
+			}
+			r.ends[i] = end
+		}
+		if start.Is4() {
+			r.v4Start = append(r.v4Start, key4(start))
+			r.v4End = append(r.v4End, key4(end))
+		} else {
+			r.v6Start = append(r.v6Start, key6(start))
+			r.v6End = append(r.v6End, key6(end))
+		}
+	}
+
+	r.head = r.head[:len(r.head):len(r.head)]
+	r.meta = r.meta[:len(r.meta):len(r.meta)]
+	r.metaIndex = r.metaIndex[:len(r.metaIndex):len(r.metaIndex)]
+	r.v4Start = r.v4Start[:len(r.v4Start):len(r.v4Start)]
+	r.v4End = r.v4End[:len(r.v4End):len(r.v4End)]
+	r.v6Start = r.v6Start[:len(r.v6Start):len(r.v6Start)]
+	r.v6End = r.v6End[:len(r.v6End):len(r.v6End)]
+	r.v4Tree = newMaxEndTree(r.v4End, lessUint32)
+	r.v6Tree = newMaxEndTree(r.v6End, lessUint128)
 	return r
 }
 
 // ASList holds a list of AS zones.
+//
+// Zones are stored sorted by StartIP as separate arrays of keys,
+// IPv4 addresses as uint32 and IPv6 addresses as uint128,
+// while the ASN, country code and description are deduplicated into a metadata table.
+// Zones without a valid StartIP sort first and are kept as is in head.
 type ASList struct {
-	s []AS
+	head []AS
+
+	v4Start []uint32
+	v4End   []uint32
+	v6Start []uint128
+	v6End   []uint128
+
+	//metaIndex maps a zone(offset by len(head)) to its metadata
+	metaIndex []uint32
+	meta      []asMeta
+
+	//ends holds the EndIP of zones whose EndIP family differs from its StartIP
+	ends map[int]netip.Addr
+
+	//v4Tree and v6Tree index the largest EndIP of the zones for FindAll
+	v4Tree maxEndTree[uint32]
+	v6Tree maxEndTree[uint128]
+
+	//policy decides which zone Find returns, rank holds the rank of every zone for the policies that need it
+	policy Policy
+	rank   []int
+}
+
+// asMeta holds the non address fields of an AS.
+type asMeta struct {
+	ASNumber      ASN
+	CountryCode   string
+	ASDescription string
 }
 
 // Find finds and returns the AS zone for a given IP address.
 // Bool indicates if AS is valid and found
-// Notice: if multiple zones claims an IP, the closest AS zone gets returned.
+// Notice: if multiple zones claims an IP, the closest AS zone gets returned,
+// unless the list has been created with another Policy.
 func (r *ASList) Find(ip netip.Addr) (AS, bool) {
+	if r.policy != PolicyClosest {
+		return r.findByPolicy(ip)
+	}
 	//get an index
 	index := r.Index(ip)
 	//when the index is negative its bellow our lower bound
@@ -33,15 +200,76 @@ func (r *ASList) Find(ip netip.Addr) (AS, bool) {
 	}
 
 	//we check if the AS actually contains the IP
-	if !r.s[index].Contains(ip) {
+	if !r.contains(index, ip) {
 		return AS{}, false
 	}
-	return r.s[index], true
+	return r.at(index), true
+}
+
+// FindAll returns every AS zone that contains given ip address, closest AS zone first like FindList.
+// Unlike FindList, the result is guaranteed to be complete, no matter how far apart the zones are.
+func (r *ASList) FindAll(ip netip.Addr) []AS {
+	index := r.findAll(ip)
+	if len(index) == 0 {
+		return nil
+	}
+	s := make([]AS, len(index))
+	for i, ix := range index {
+		s[i] = r.at(ix)
+	}
+	return s
+}
+
+// findAll returns the index of every AS zone that contains ip, in descending order.
+func (r *ASList) findAll(ip netip.Addr) []int {
+	index := r.oddZones(ip, ip)
+	add := func(i int) {
+		if _, odd := r.ends[i]; !odd && r.contains(i, ip) {
+			index = append(index, i)
+		}
+	}
+
+	base := len(r.head)
+	switch {
+	case ip.Is4():
+		r.v4Tree.overlap(r.v4Start, r.v4End, key4(ip), key4(ip), lessUint32, func(i int) {
+			add(base + i)
+		})
+	case ip.Is6():
+		base += len(r.v4Start)
+		r.v6Tree.overlap(r.v6Start, r.v6End, key6(ip), key6(ip), lessUint128, func(i int) {
+			add(base + i)
+		})
+	}
+
+	sort.Sort(sort.Reverse(sort.IntSlice(index)))
+	return index
+}
+
+// oddZones returns the index of every zone without a valid StartIP or with mixed families,
+// that intersects lo to hi inclusive. These zones are rare, so they are checked one by one.
+func (r *ASList) oddZones(lo, hi netip.Addr) []int {
+	var index []int
+	intersects := func(as AS) bool {
+		return as.StartIP.Compare(hi) <= 0 && as.EndIP.Compare(lo) >= 0
+	}
+	for i, as := range r.head {
+		if intersects(as) {
+			index = append(index, i)
+		}
+	}
+	for i := range r.ends {
+		if intersects(r.at(i)) {
+			index = append(index, i)
+		}
+	}
+	return index
 }
 
 // FindList attempts to find and return neighbouring AS that contain given ip address.
 // search dictate how many invalid AS zones to skip before returning.
 // This method is only useful when an IP has been claimed by multiple AS zones.
+// See FindAll for a complete list of AS zones containing the ip.
 func (r *ASList) FindList(ip netip.Addr, search uint) []AS {
 	//get an index
 	index := r.Index(ip)
@@ -58,8 +286,8 @@ func (r *ASList) FindList(ip netip.Addr, search uint) []AS {
 			break
 		}
 		//if the AS contains the IP, we add it to the slice
-		if r.s[ix].Contains(ip) {
-			s = append(s, r.s[ix])
+		if r.contains(ix, ip) {
+			s = append(s, r.at(ix))
 			//expand the search space for every valid result
 			search++
 		}
@@ -72,14 +300,23 @@ func (r *ASList) FindList(ip netip.Addr, search uint) []AS {
 // Does not guarantee the AS of said index contains the IP.
 // If the index is out of bounds, it returns -1.
 func (r *ASList) Index(ip netip.Addr) int {
-	//we use sort.Search to find the closest index, using the AS zone's StartIP as comparison
-	index := sort.Search(len(r.s),
-		func(i int) bool {
-			return ip.Less(r.s[i].StartIP)
-		})
+	base := len(r.head)
+	var index int
+	switch {
+	case ip.Is4():
+		index = base + search4(r.v4Start, key4(ip))
+	case ip.Is6() && ip.Zone() == "":
+		index = base + len(r.v4Start) + search6(r.v6Start, key6(ip))
+	default:
+		//we use sort.Search to find the closest index, using the AS zone's StartIP as comparison
+		index = sort.Search(r.IndexLen(),
+			func(i int) bool {
+				return ip.Less(r.at(i).StartIP)
+			})
+	}
 	//index is actually off by one, so we decrement it
 	index--
-	if index < 0 || index >= len(r.s) {
+	if index < 0 || index >= r.IndexLen() {
 		return -1
 	}
 
@@ -89,13 +326,131 @@ func (r *ASList) Index(ip netip.Addr) int {
 // FromIndex returns an AS zone at a given index.
 // Returns false if the index is out of bounds.
 func (r *ASList) FromIndex(i int) (AS, bool) {
-	if i < 0 || i >= len(r.s) {
+	if i < 0 || i >= r.IndexLen() {
 		return AS{}, false
 	}
-	return r.s[i], true
+	return r.at(i), true
 }
 
 // IndexLen returns the length of the AS zone.
 func (r *ASList) IndexLen() int {
-	return len(r.s)
+	return len(r.head) + len(r.metaIndex)
+}
+
+// at returns the AS zone at index i, which must be in bounds.
+func (r *ASList) at(i int) AS {
+	if i < len(r.head) {
+		return r.head[i]
+	}
+	j := i - len(r.head)
+	m := r.meta[r.metaIndex[j]]
+	as := AS{
+		ASNumber:      m.ASNumber,
+		CountryCode:   m.CountryCode,
+		ASDescription: m.ASDescription,
+	}
+	if j < len(r.v4Start) {
+		as.StartIP, as.EndIP = addr4(r.v4Start[j]), addr4(r.v4End[j])
+	} else {
+		j -= len(r.v4Start)
+		as.StartIP, as.EndIP = r.v6Start[j].addr(), r.v6End[j].addr()
+	}
+	if end, ok := r.ends[i]; ok {
+		as.EndIP = end
+	}
+	return as
+}
+
+// zones returns all AS zones in order.
+func (r *ASList) zones() []AS {
+	s := make([]AS, r.IndexLen())
+	for i := range s {
+		s[i] = r.at(i)
+	}
+	return s
+}
+
+// contains checks if the AS zone at index i contains ip, given ip is not below its StartIP.
+// The keys are compared directly when ip is of the same family as the zone.
+func (r *ASList) contains(i int, ip netip.Addr) bool {
+	j := i - len(r.head)
+	if _, odd := r.ends[i]; j >= 0 && !odd {
+		if j < len(r.v4Start) {
+			if ip.Is4() {
+				return key4(ip) <= r.v4End[j]
+			}
+		} else if ip.Is6() && ip.Zone() == "" {
+			return !r.v6End[j-len(r.v4Start)].less(key6(ip))
+		}
+	}
+	return r.at(i).Contains(ip)
+}
+
+// search4 returns the number of keys less than or equal to key.
+func search4(keys []uint32, key uint32) int {
+	lo, hi := 0, len(keys)
+	for lo < hi {
+		mid := int(uint(lo+hi) >> 1)
+		if key < keys[mid] {
+			hi = mid
+		} else {
+			lo = mid + 1
+		}
+	}
+	return lo
+}
+
+// search6 returns the number of keys less than or equal to key.
+func search6(keys []uint128, key uint128) int {
+	lo, hi := 0, len(keys)
+	for lo < hi {
+		mid := int(uint(lo+hi) >> 1)
+		if key.less(keys[mid]) {
+			hi = mid
+		} else {
+			lo = mid + 1
+		}
+	}
+	return lo
+}
+
+// uint128 is an IPv6 address as a 128-bit unsigned integer.
+type uint128 struct {
+	hi, lo uint64
+}
+
+func (u uint128) less(v uint128) bool {
+	return u.hi < v.hi || u.hi == v.hi && u.lo < v.lo
+}
+
+func (u uint128) addr() netip.Addr {
+	var b [16]byte
+	binary.BigEndian.PutUint64(b[:8], u.hi)
+	binary.BigEndian.PutUint64(b[8:], u.lo)
+	return netip.AddrFrom16(b)
+}
+
+func key6(ip netip.Addr) uint128 {
+	if !ip.IsValid() {
+		return uint128{}
+	}
+	b := ip.As16()
+	return uint128{hi: binary.BigEndian.Uint64(b[:8]), lo: binary.BigEndian.Uint64(b[8:])}
+}
+
+func key4(ip netip.Addr) uint32 {
+	if !ip.Is4() {
+		if ip.IsValid() {
+			return math.MaxUint32
+		}
+		return 0
+	}
+	b := ip.As4()
+	return binary.BigEndian.Uint32(b[:])
+}
+
+func addr4(k uint32) netip.Addr {
+	var b [4]byte
+	binary.BigEndian.PutUint32(b[:], k)
+	return netip.AddrFrom4(b)
 }
@@ -1,6 +1,7 @@
 package asndb
 
 import (
+	"math/rand"
 	"net/netip"
 	"reflect"
 	"sort"
@@ -11,7 +12,7 @@ func TestNewRegistry(t *testing.T) {
 	tests := []struct {
 		name        string
 		asn         []AS
-		wantASN     []int
+		wantASN     []ASN
 		wantZoneLen int
 	}{
 		{
@@ -51,17 +52,17 @@ func TestNewRegistry(t *testing.T) {
 					ASNumber: 2,
 				},
 			},
-			wantASN:     []int{0, 1, 2, 3, 2, 2, 4},
+			wantASN:     []ASN{0, 1, 2, 3, 2, 2, 4},
 			wantZoneLen: 7,
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			got := NewASList(tt.asn)
-			var asl []int
+			var asl []ASN
 			for i, want := range tt.wantASN {
-				gotAsn := got.s[i].ASNumber
-				asl = append(asl, got.s[i].ASNumber)
+				gotAsn := got.at(i).ASNumber
+				asl = append(asl, got.at(i).ASNumber)
 				if gotAsn != want {
 					t.Errorf("ASN.s[%d].ASNumber = %v, want %v", i, gotAsn, want)
 				}
@@ -70,7 +71,7 @@ func TestNewRegistry(t *testing.T) {
 				t.Logf("wanted asn: %v", tt.wantASN)
 				t.Logf("got asn: %v", asl)
 			}
-			gotZoneLen := len(got.s)
+			gotZoneLen := got.IndexLen()
 			if gotZoneLen != tt.wantZoneLen {
 				t.Errorf("ASN.ZoneLen() = %v, want %v", gotZoneLen, tt.wantZoneLen)
 			}
@@ -83,7 +84,7 @@ func TestRegistry_Find(t *testing.T) {
 		name      string
 		ip        netip.Addr
 		wantEmpty bool
-		wantASN   int
+		wantASN   ASN
 		wantAS    AS
 	}
 	tests := []struct {
@@ -330,7 +331,7 @@ func TestRegistry_FindList(t *testing.T) {
 		name    string
 		ip      netip.Addr
 		search  uint
-		wantASN []int
+		wantASN []ASN
 		//wantAS  []AS
 	}
 	tests := []struct {
@@ -391,27 +392,27 @@ func TestRegistry_FindList(t *testing.T) {
 					name:    "first",
 					ip:      netip.MustParseAddr("1.80.0.0"),
 					search:  3,
-					wantASN: []int{4, 3, 3, 2, 2, 1, 1},
+					wantASN: []ASN{4, 3, 3, 2, 2, 1, 1},
 				}, {
 					name:    "second",
 					ip:      netip.MustParseAddr("1.80.0.0"),
 					search:  2,
-					wantASN: []int{4, 3, 3},
+					wantASN: []ASN{4, 3, 3},
 				}, {
 					name:    "third",
 					ip:      netip.MustParseAddr("1.80.0.0"),
 					search:  1,
-					wantASN: []int{4, 3},
+					wantASN: []ASN{4, 3},
 				}, {
 					name:    "fourth",
 					ip:      netip.MustParseAddr("1.80.0.0"),
 					search:  0,
-					wantASN: []int{4},
+					wantASN: []ASN{4},
 				}, {
 					name:    "last",
 					ip:      netip.MustParseAddr("5.5.0.0"),
 					search:  1,
-					wantASN: []int{5},
+					wantASN: []ASN{5},
 				},
 			},
 		}, {
@@ -501,47 +502,47 @@ func TestRegistry_FindList(t *testing.T) {
 					name:    "zero",
 					ip:      netip.MustParseAddr("1.80.0.0"),
 					search:  0,
-					wantASN: []int{10, 9, 8},
+					wantASN: []ASN{10, 9, 8},
 				}, {
 					name:    "one",
 					ip:      netip.MustParseAddr("1.80.0.0"),
 					search:  1,
-					wantASN: []int{10, 9, 8, 7, 6},
+					wantASN: []ASN{10, 9, 8, 7, 6},
 				}, {
 					name:    "two",
 					ip:      netip.MustParseAddr("1.80.0.0"),
 					search:  2,
-					wantASN: []int{10, 9, 8, 7, 6, 5, 5, 4},
+					wantASN: []ASN{10, 9, 8, 7, 6, 5, 5, 4},
 				}, {
 					name:    "three",
 					ip:      netip.MustParseAddr("1.80.0.0"),
 					search:  3,
-					wantASN: []int{10, 9, 8, 7, 6, 5, 5, 4, 3},
+					wantASN: []ASN{10, 9, 8, 7, 6, 5, 5, 4, 3},
 				}, {
 					name:    "four",
 					ip:      netip.MustParseAddr("1.80.0.0"),
 					search:  4,
-					wantASN: []int{10, 9, 8, 7, 6, 5, 5, 4, 3, 2, 2, 1, 1},
+					wantASN: []ASN{10, 9, 8, 7, 6, 5, 5, 4, 3, 2, 2, 1, 1},
 				}, {
 					name:    "five",
 					ip:      netip.MustParseAddr("1.80.0.0"),
 					search:  5,
-					wantASN: []int{10, 9, 8, 7, 6, 5, 5, 4, 3, 2, 2, 1, 1},
+					wantASN: []ASN{10, 9, 8, 7, 6, 5, 5, 4, 3, 2, 2, 1, 1},
 				}, {
 					name:    "ten",
 					ip:      netip.MustParseAddr("1.80.0.0"),
 					search:  10,
-					wantASN: []int{10, 9, 8, 7, 6, 5, 5, 4, 3, 2, 2, 1, 1},
+					wantASN: []ASN{10, 9, 8, 7, 6, 5, 5, 4, 3, 2, 2, 1, 1},
 				}, {
 					name:    "last",
 					ip:      netip.MustParseAddr("10.0.0.0"),
 					search:  0,
-					wantASN: []int{11, 10},
+					wantASN: []ASN{11, 10},
 				}, {
 					name:    "5-1",
 					ip:      netip.MustParseAddr("1.64.0.0"),
 					search:  2,
-					wantASN: []int{5, 5, 4, 3, 2, 2, 1, 1},
+					wantASN: []ASN{5, 5, 4, 3, 2, 2, 1, 1},
 				},
 			},
 		},
@@ -552,7 +553,7 @@ func TestRegistry_FindList(t *testing.T) {
 			for _, tt := range tc.lookups {
 				t.Run(tt.name, func(t *testing.T) {
 					gotASList := r.FindList(tt.ip, tt.search)
-					col := make([]int, 0, len(gotASList))
+					col := make([]ASN, 0, len(gotASList))
 					if len(gotASList) != len(tt.wantASN) {
 						t.Errorf("ASList.FindList() length = %v, want %v", len(gotASList), len(tt.wantASN))
 					}
@@ -646,7 +647,7 @@ func TestRegistry_Index(t *testing.T) {
 						s = "*"
 					}
 
-					t.Logf("%ss[%d] = %v", s, i, tt.r.s[i].StartIP)
+					t.Logf("%ss[%d] = %v", s, i, tt.r.at(i).StartIP)
 				}
 			}
 		})
@@ -664,7 +665,7 @@ func TestASList_FromIndex(t *testing.T) {
 		name      string
 		r         *ASList
 		idx       int
-		wantASN   int
+		wantASN   ASN
 		wantFound bool
 	}{
 		{name: "-2", r: reg1, idx: -2, wantASN: 0, wantFound: false},
@@ -685,8 +686,8 @@ func TestASList_FromIndex(t *testing.T) {
 		})
 	}
 	t.Run("Length", func(t *testing.T) {
-		if got := reg1.IndexLen(); got != len(reg1.s) {
-			t.Errorf("ASList.Length() = %v, want %v", got, len(reg1.s))
+		if got := reg1.IndexLen(); got != len(reg1.zones()) {
+			t.Errorf("ASList.Length() = %v, want %v", got, len(reg1.zones()))
 		}
 	})
 }
@@ -731,16 +732,17 @@ func TestRegistry_Integration(t *testing.T) {
 		},
 	}
 	r := NewASList(ASNs)
-	wantASNOrder := []int{0, 1, 2, 3, 2, 2, 4}
+	wantASNOrder := []ASN{0, 1, 2, 3, 2, 2, 4}
 	wantZoneLen := 7
 
 	wantFind := []struct {
-		ip      netip.Addr
-		wantASN int
+		ip       netip.Addr
+		wantASN  ASN
+		notFound bool
 	}{
 		{
-			ip:      netip.MustParseAddr("0.0.0.0"),
-			wantASN: -1,
+			ip:       netip.MustParseAddr("0.0.0.0"),
+			notFound: true,
 		}, {
 			ip:      netip.MustParseAddr("1.0.0.0"),
 			wantASN: 0,
@@ -751,18 +753,18 @@ func TestRegistry_Integration(t *testing.T) {
 			ip:      netip.MustParseAddr("7.0.0.0"),
 			wantASN: 4,
 		}, {
-			ip:      netip.MustParseAddr("200.0.0.0"),
-			wantASN: -1,
+			ip:       netip.MustParseAddr("200.0.0.0"),
+			notFound: true,
 		},
 	}
 
 	t.Run("Alter Input", func(t *testing.T) {
 		for i, a := range ASNs {
-			a.ASNumber = -999
+			a.ASNumber = 999999
 			ASNs[i] = a
 		}
-		for _, a := range r.s {
-			if a.ASNumber == -999 {
+		for _, a := range r.zones() {
+			if a.ASNumber == 999999 {
 				t.Errorf("ASN.s should not have been altered")
 				return
 			}
@@ -770,9 +772,9 @@ func TestRegistry_Integration(t *testing.T) {
 	})
 
 	t.Run("Order", func(t *testing.T) {
-		var order []int
-		for i := 0; i < len(r.s); i++ {
-			gotAsn := r.s[i].ASNumber
+		var order []ASN
+		for i := 0; i < r.IndexLen(); i++ {
+			gotAsn := r.at(i).ASNumber
 			if gotAsn != wantASNOrder[i] {
 				t.Errorf("ASN.s[%d].ASNumber = %v, want %v", i, order[i], wantASNOrder[i])
 			}
@@ -785,7 +787,7 @@ func TestRegistry_Integration(t *testing.T) {
 	})
 
 	t.Run("ZoneLen", func(t *testing.T) {
-		gotZoneLen := len(r.s)
+		gotZoneLen := r.IndexLen()
 		if gotZoneLen != wantZoneLen {
 			t.Errorf("ASN.ZoneLen() = %v, want %v", gotZoneLen, wantZoneLen)
 		}
@@ -794,7 +796,7 @@ func TestRegistry_Integration(t *testing.T) {
 	t.Run("Find", func(t *testing.T) {
 		for _, s := range wantFind {
 			a, b := r.Find(s.ip)
-			if s.wantASN == -1 {
+			if s.notFound {
 				d := AS{}
 				if a != d {
 					t.Errorf("ASN.Find(%v).ASNumber = %#v, wanted empty", s.ip, a)
@@ -813,7 +815,7 @@ func TestRegistry_Integration(t *testing.T) {
 		}
 	})
 
-	_ = r.s[0].String()
+	_ = r.at(0).String()
 }
 
 func TestAsSort(t *testing.T) {
@@ -840,7 +842,7 @@ func TestAsSort(t *testing.T) {
 		c := asSortIP(clone(asl))
 		sort.Sort(c)
 		for i, as := range c {
-			if as.ASNumber != i {
+			if as.ASNumber != ASN(i) {
 				t.Errorf("list[%d].ASNumber = %v, want %v", i, as.ASNumber, i)
 			}
 		}
@@ -855,7 +857,7 @@ func TestAsSort(t *testing.T) {
 		c := asSortASN(clone(asl))
 		sort.Sort(c)
 		for i, as := range c {
-			if as.ASNumber != i {
+			if as.ASNumber != ASN(i) {
 				t.Errorf("list[%d].ASNumber = %v, want %v", i, as.ASNumber, i)
 			}
 		}
@@ -867,3 +869,112 @@ func TestAsSort(t *testing.T) {
 		}
 	})
 }
+
+func TestASList_Layout(t *testing.T) {
+	asl := []AS{
+		{ASNumber: 1, StartIP: netip.MustParseAddr("1.0.0.0"), EndIP: netip.MustParseAddr("1.0.0.255"), CountryCode: "US"},
+		{ASNumber: 1, StartIP: netip.MustParseAddr("1.0.2.0"), EndIP: netip.MustParseAddr("1.0.2.255"), CountryCode: "US"},
+		{ASNumber: 2, StartIP: netip.MustParseAddr("255.255.255.0"), EndIP: netip.MustParseAddr("255.255.255.255")},
+		{ASNumber: 3, StartIP: netip.MustParseAddr("::"), EndIP: netip.MustParseAddr("::ff")},
+		{ASNumber: 4, StartIP: netip.MustParseAddr("::ffff:1.0.0.0"), EndIP: netip.MustParseAddr("::ffff:1.0.0.255")},
+		{ASNumber: 5, StartIP: netip.MustParseAddr("2001:db8::"), EndIP: netip.MustParseAddr("2001:db8:ffff:ffff:ffff:ffff:ffff:ffff")},
+		{ASNumber: 6, StartIP: netip.MustParseAddr("ffff::"), EndIP: netip.MustParseAddr("ffff:ffff:ffff:ffff:ffff:ffff:ffff:ffff")},
+		{ASNumber: 7, StartIP: netip.MustParseAddr("9.0.0.0"), EndIP: netip.MustParseAddr("::1")},
+		{ASNumber: 8, StartIP: netip.MustParseAddr("10.0.0.0")},
+		{ASNumber: 9, ASDescription: "no addresses"},
+	}
+	r := NewASList(asl)
+
+	want := clone(asl)
+	sort.Stable(asSortIP(want))
+	if got := r.zones(); !reflect.DeepEqual(got, want) {
+		t.Errorf("ASList.zones() = %v, want %v", got, want)
+	}
+	if len(r.meta) != 8 {
+		t.Errorf("ASList.meta len() = %v, want 8 deduplicated entries", len(r.meta))
+	}
+
+	//every lookup must match a plain search over the sorted zones
+	for _, ip := range []string{"0.0.0.0", "1.0.0.0", "1.0.0.255", "1.0.1.0", "1.0.2.128", "9.9.9.9", "10.0.0.0",
+		"255.255.255.255", "::", "::1", "::100", "::ffff:1.0.0.1", "2001:db8::1", "2001:db9::", "ffff::1",
+		"ffff:ffff:ffff:ffff:ffff:ffff:ffff:ffff", "fe80::1%eth0"} {
+		addr := netip.MustParseAddr(ip)
+		wantIndex := sort.Search(len(want), func(i int) bool { return addr.Less(want[i].StartIP) }) - 1
+		if got := r.Index(addr); got != wantIndex {
+			t.Errorf("ASList.Index(%v) = %v, want %v", addr, got, wantIndex)
+		}
+		var wantAS AS
+		if wantIndex >= 0 && want[wantIndex].Contains(addr) {
+			wantAS = want[wantIndex]
+		}
+		if got, _ := r.Find(addr); got != wantAS {
+			t.Errorf("ASList.Find(%v) = %v, want %v", addr, got, wantAS)
+		}
+	}
+}
+
+func TestASList_FindAll(t *testing.T) {
+	t.Run("Far Apart", func(t *testing.T) {
+		//a large zone followed by many small zones that FindList can't see past
+		asl := []AS{{ASNumber: 1, StartIP: netip.MustParseAddr("1.0.0.0"), EndIP: netip.MustParseAddr("1.255.255.255")}}
+		for i := 0; i < 200; i++ {
+			start := netip.AddrFrom4([4]byte{1, byte(i), 0, 0})
+			asl = append(asl, AS{ASNumber: 100, StartIP: start, EndIP: netip.AddrFrom4([4]byte{1, byte(i), 0, 255})})
+		}
+		asl = append(asl, AS{ASNumber: 2, StartIP: netip.MustParseAddr("1.250.0.0"), EndIP: netip.MustParseAddr("1.250.255.255")})
+		r := NewASList(asl)
+
+		got := r.FindAll(netip.MustParseAddr("1.250.0.1"))
+		var gotASN []ASN
+		for _, as := range got {
+			gotASN = append(gotASN, as.ASNumber)
+		}
+		if wantASN := []ASN{2, 1}; !reflect.DeepEqual(gotASN, wantASN) {
+			t.Errorf("ASList.FindAll() = %v, want %v", gotASN, wantASN)
+		}
+		if got := r.FindAll(netip.MustParseAddr("2.0.0.0")); got != nil {
+			t.Errorf("ASList.FindAll() = %v, want nil", got)
+		}
+	})
+
+	t.Run("Random", func(t *testing.T) {
+		rnd := rand.New(rand.NewSource(1))
+		for round := 0; round < 20; round++ {
+			var asl []AS
+			n := rnd.Intn(300)
+			for i := 0; i < n; i++ {
+				start, end := rnd.Uint32()>>20, rnd.Uint32()>>20
+				if start > end {
+					start, end = end, start
+				}
+				if rnd.Intn(4) == 0 {
+					asl = append(asl, AS{ASNumber: ASN(i), StartIP: addr4(start), EndIP: addr4(end)})
+				} else {
+					asl = append(asl, AS{ASNumber: ASN(i), StartIP: key6From(start), EndIP: key6From(end)})
+				}
+			}
+			r := NewASList(asl)
+			want := r.zones()
+
+			for q := 0; q < 200; q++ {
+				ip := addr4(rnd.Uint32() >> 20)
+				if q%2 == 0 {
+					ip = key6From(rnd.Uint32() >> 20)
+				}
+				var wantAS []AS
+				for i := len(want) - 1; i >= 0; i-- {
+					if want[i].Contains(ip) {
+						wantAS = append(wantAS, want[i])
+					}
+				}
+				if got := r.FindAll(ip); !reflect.DeepEqual(got, wantAS) {
+					t.Fatalf("ASList.FindAll(%v) = %v, want %v", ip, got, wantAS)
+				}
+			}
+		}
+	})
+}
+
+func key6From(k uint32) netip.Addr {
+	return uint128{hi: 0x20010db8 << 32, lo: uint64(k)}.addr()
+}
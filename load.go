@@ -2,59 +2,223 @@ package asndb
 
 import (
 	"bufio"
-	"compress/gzip"
+	"errors"
 	"fmt"
 	"io"
-	"net/http"
 	"net/netip"
 	"strconv"
 	"strings"
 )
 
+// Errors describing why a row was rejected, wrapped by RowError.Reason.
+var (
+	ErrMalformedRow   = errors.New("invalid data")
+	ErrInvalidAddress = errors.New("invalid address")
+	ErrInvalidASN     = errors.New("invalid asn")
+	ErrInvertedRange  = errors.New("inverted range")
+	ErrMixedFamily    = errors.New("mixed address family")
+)
+
+// LoadMode dictates how loaders react to malformed rows.
+type LoadMode int
+
+const (
+	// LoadStrict aborts loading on the first malformed row.
+	LoadStrict LoadMode = iota
+	// LoadLenient skips malformed rows and reports them as a *LoadError once loading finishes.
+	LoadLenient
+)
+
+// TSVFormat is the format of the addresses in iptoasn tsv data.
+type TSVFormat int
+
+const (
+	// TSVAuto detects the format from the first row.
+	TSVAuto TSVFormat = iota
+	// TSVAddr has addresses in text form, as in ip2asn-combined, ip2asn-v4 and ip2asn-v6.
+	TSVAddr
+	// TSVU32 has IPv4 addresses as unsigned 32-bit integers, as in ip2asn-v4-u32.
+	TSVU32
+)
+
+// LoadOptions configures the loaders.
+// The zero value is a strict loader with the default line size limit.
+type LoadOptions struct {
+	Mode LoadMode
+	// MaxLineSize is the longest line accepted in bytes, defaults to bufio.MaxScanTokenSize.
+	MaxLineSize int
+	// Format is the address format of tsv data, it's detected by default.
+	Format TSVFormat
+}
+
+// RowError describes a row that failed to parse.
+type RowError struct {
+	// Line is the 1-based line number of the row.
+	Line int
+	// Text is the raw text of the row.
+	Text string
+	// Reason is why the row got rejected, it wraps one of the Err* values.
+	Reason error
+}
+
+func (e *RowError) Error() string {
+	return fmt.Sprintf("line %d: %v", e.Line, e.Reason)
+}
+
+func (e *RowError) Unwrap() error {
+	return e.Reason
+}
+
+// LoadError is returned by lenient loaders when rows have been skipped.
+// The data returned alongside it contains every row that parsed successfully.
+type LoadError struct {
+	Rows []*RowError
+}
+
+func (e *LoadError) Error() string {
+	if len(e.Rows) == 1 {
+		return fmt.Sprintf("skipped 1 row: %v", e.Rows[0])
+	}
+	return fmt.Sprintf("skipped %d rows, first: %v", len(e.Rows), e.Rows[0])
+}
+
+// LoadFromTSV parses the tsv data from iptoasn in strict mode.
+// See LoadFromTSVWithOptions.
 func LoadFromTSV(reader io.Reader) ([]AS, error) {
-	var s []AS
-	buf := bufio.NewScanner(reader)
-	var i int
-	for buf.Scan() {
-		parts := strings.Split(buf.Text(), "\t")
+	return LoadFromTSVWithOptions(reader, LoadOptions{})
+}
 
-		if len(parts) < 5 {
-			return s, fmt.Errorf(`invalid data #%d: want 5 parts got %d`, i, len(parts))
+// LoadFromTSVWithOptions parses the tsv data from iptoasn.
+// All of the combined, per-family and u32 data sets are supported,
+// unless LoadOptions.Format is set the format is detected from the first row.
+// Rows with a malformed ASN, an invalid address, an inverted range or mixed address families are rejected,
+// in strict mode the first rejected row gets returned as a *RowError,
+// in lenient mode all rejected rows are skipped and returned as a *LoadError.
+// Read errors such as bufio.ErrTooLong abort loading in both modes, alongside all the rows parsed up until then.
+func LoadFromTSVWithOptions(reader io.Reader, opts LoadOptions) ([]AS, error) {
+	var s []AS
+	format := opts.Format
+	err := scanRows(reader, opts, func(line string) error {
+		if format == TSVAuto {
+			format = detectTSVFormat(line)
 		}
-
-		asNumber, _ := strconv.Atoi(parts[2])
-		start, err := netip.ParseAddr(parts[0])
+		as, err := parseTSVRow(line, format)
 		if err != nil {
-			return s, fmt.Errorf("invalid start address #%d: %w", i, err)
+			return err
 		}
-		end, err := netip.ParseAddr(parts[1])
-		if err != nil {
-			return s, fmt.Errorf("invalid end address #%d: %w", i, err)
+		s = append(s, as)
+		return nil
+	})
+	return s, err
+}
+
+// detectTSVFormat detects the format of a row, integer start addresses are TSVU32.
+func detectTSVFormat(line string) TSVFormat {
+	start, _, _ := strings.Cut(line, "\t")
+	if start == "" {
+		return TSVAddr
+	}
+	for _, c := range start {
+		if c < '0' || c > '9' {
+			return TSVAddr
 		}
-		s = append(s, AS{
-			StartIP:       start,
-			EndIP:         end,
-			ASNumber:      asNumber,
-			CountryCode:   parts[3],
-			ASDescription: parts[4],
-		})
-		i++
 	}
-	return s, nil
+	return TSVU32
 }
 
-const DownloadViaIpToAsn = "https://iptoasn.com/data/ip2asn-combined.tsv.gz"
+func parseTSVRow(line string, format TSVFormat) (AS, error) {
+	parts := strings.Split(line, "\t")
+	if len(parts) < 5 {
+		return AS{}, fmt.Errorf(`%w: want 5 parts got %d`, ErrMalformedRow, len(parts))
+	}
+
+	var start, end netip.Addr
+	var err error
+	if format == TSVU32 {
+		start, end, err = parseU32Range(parts[0], parts[1])
+	} else {
+		start, end, err = parseRange(parts[0], parts[1])
+	}
+	if err != nil {
+		return AS{}, err
+	}
+	asNumber, err := ParseASN(parts[2])
+	if err != nil {
+		return AS{}, err
+	}
+	return AS{
+		StartIP:       start,
+		EndIP:         end,
+		ASNumber:      asNumber,
+		CountryCode:   parts[3],
+		ASDescription: parts[4],
+	}, nil
+}
 
-func DownloadFromURL(url string) (io.ReadCloser, error) {
-	rs, err := http.Get(url)
+// parseRange parses and validates a StartIP and EndIP pair.
+func parseRange(startText, endText string) (netip.Addr, netip.Addr, error) {
+	start, err := netip.ParseAddr(startText)
 	if err != nil {
-		return nil, err
+		return netip.Addr{}, netip.Addr{}, fmt.Errorf("%w: start: %v", ErrInvalidAddress, err)
 	}
+	end, err := netip.ParseAddr(endText)
+	if err != nil {
+		return netip.Addr{}, netip.Addr{}, fmt.Errorf("%w: end: %v", ErrInvalidAddress, err)
+	}
+	return start, end, checkRange(start, end)
+}
 
-	gzipReader, err := gzip.NewReader(rs.Body)
+// parseU32Range parses and validates a StartIP and EndIP pair of IPv4 addresses in integer form.
+func parseU32Range(startText, endText string) (netip.Addr, netip.Addr, error) {
+	start, err := strconv.ParseUint(startText, 10, 32)
+	if err != nil {
+		return netip.Addr{}, netip.Addr{}, fmt.Errorf("%w: start: %q", ErrInvalidAddress, startText)
+	}
+	end, err := strconv.ParseUint(endText, 10, 32)
 	if err != nil {
-		return nil, err
+		return netip.Addr{}, netip.Addr{}, fmt.Errorf("%w: end: %q", ErrInvalidAddress, endText)
 	}
+	startIP, endIP := addr4(uint32(start)), addr4(uint32(end))
+	return startIP, endIP, checkRange(startIP, endIP)
+}
 
-	return gzipReader, nil
+// checkRange validates that start and end form a usable range.
+func checkRange(start, end netip.Addr) error {
+	if start.Is4() != end.Is4() {
+		return fmt.Errorf("%w: %s->%s", ErrMixedFamily, start, end)
+	}
+	if start.Compare(end) > 0 {
+		return fmt.Errorf("%w: %s->%s", ErrInvertedRange, start, end)
+	}
+	return nil
+}
+
+// scanRows feeds every line of reader into parse, handling rejected rows according to opts.
+func scanRows(reader io.Reader, opts LoadOptions, parse func(line string) error) error {
+	buf := bufio.NewScanner(reader)
+	if opts.MaxLineSize > 0 {
+		buf.Buffer(nil, opts.MaxLineSize)
+	}
+	var rejected []*RowError
+	var line int
+	for buf.Scan() {
+		line++
+		text := buf.Text()
+		err := parse(text)
+		if err == nil {
+			continue
+		}
+		rowErr := &RowError{Line: line, Text: text, Reason: err}
+		if opts.Mode != LoadLenient {
+			return rowErr
+		}
+		rejected = append(rejected, rowErr)
+	}
+	if err := buf.Err(); err != nil {
+		return fmt.Errorf("read line %d: %w", line+1, err)
+	}
+	if len(rejected) > 0 {
+		return &LoadError{Rows: rejected}
+	}
+	return nil
 }
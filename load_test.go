@@ -1,7 +1,10 @@
 package asndb
 
 import (
+	"bufio"
+	"errors"
 	"net/netip"
+	"reflect"
 	"strings"
 	"testing"
 )
@@ -55,15 +58,31 @@ func TestLoadFromTSV(t *testing.T) {
 		}, {
 			name:      "invalid parts",
 			data:      "foo\tbar\tbaz",
-			wantError: "invalid data #0: want 5 parts got 3",
+			wantError: "line 1: invalid data: want 5 parts got 3",
 		}, {
 			name:      "invalid start address",
 			data:      "foo\tbar\tbaz\tqux\tquux",
-			wantError: "invalid start address #0:",
+			wantError: "line 1: invalid address: start:",
 		}, {
 			name:      "invalid end address",
 			data:      "1.0.0.1\tbar\tbaz\tqux\tquux",
-			wantError: "invalid end address #0:",
+			wantError: "line 1: invalid address: end:",
+		}, {
+			name:      "invalid asn",
+			data:      "1.0.0.0\t1.0.0.255\t13335\tUS\tCLOUDFLARENET\n1.0.1.0\t1.0.3.255\tbaz\tqux\tquux",
+			wantError: `line 2: invalid asn "baz"`,
+		}, {
+			name:      "negative asn",
+			data:      "1.0.1.0\t1.0.3.255\t-1\tqux\tquux",
+			wantError: `line 1: invalid asn "-1"`,
+		}, {
+			name:      "inverted range",
+			data:      "1.0.3.255\t1.0.1.0\t1\tqux\tquux",
+			wantError: "line 1: inverted range: 1.0.3.255->1.0.1.0",
+		}, {
+			name:      "mixed family",
+			data:      "1.0.0.0\t::1\t1\tqux\tquux",
+			wantError: "line 1: mixed address family: 1.0.0.0->::1",
 		},
 	}
 	for _, tt := range tests {
@@ -92,3 +111,140 @@ func TestLoadFromTSV(t *testing.T) {
 		})
 	}
 }
+
+func TestLoadFromTSVWithOptions(t *testing.T) {
+	data := "1.0.0.0\t1.0.0.255\t13335\tUS\tCLOUDFLARENET\n" +
+		"1.0.1.0\t1.0.3.255\tbaz\tNone\tNot routed\n" +
+		"1.0.4.0\t1.0.7.255\t38803\tAU\tWPL-AS-AP Wirefreebroadband Pty Ltd\n" +
+		"1.0.9.0\t1.0.8.0\t1\tqux\tquux\n" +
+		"2001:200::\t2001:200:5ff:ffff:ffff:ffff:ffff:ffff\t2500\tJP\tWIDE-BB WIDE Project"
+
+	t.Run("Strict", func(t *testing.T) {
+		ls, err := LoadFromTSVWithOptions(strings.NewReader(data), LoadOptions{Mode: LoadStrict})
+		var rowErr *RowError
+		if !errors.As(err, &rowErr) {
+			t.Fatalf("LoadFromTSVWithOptions() error = %v, want *RowError", err)
+		}
+		if rowErr.Line != 2 || !errors.Is(err, ErrInvalidASN) {
+			t.Errorf("LoadFromTSVWithOptions() error = %v, want invalid asn on line 2", err)
+		}
+		if len(ls) != 1 {
+			t.Errorf("LoadFromTSVWithOptions() = %v, want 1 row before the error", len(ls))
+		}
+	})
+
+	t.Run("Lenient", func(t *testing.T) {
+		ls, err := LoadFromTSVWithOptions(strings.NewReader(data), LoadOptions{Mode: LoadLenient})
+		var loadErr *LoadError
+		if !errors.As(err, &loadErr) {
+			t.Fatalf("LoadFromTSVWithOptions() error = %v, want *LoadError", err)
+		}
+		wantRows := []struct {
+			line   int
+			reason error
+		}{
+			{line: 2, reason: ErrInvalidASN},
+			{line: 4, reason: ErrInvertedRange},
+		}
+		if len(loadErr.Rows) != len(wantRows) {
+			t.Fatalf("LoadError.Rows = %v, want %v rows", loadErr.Rows, len(wantRows))
+		}
+		for i, want := range wantRows {
+			got := loadErr.Rows[i]
+			if got.Line != want.line || !errors.Is(got, want.reason) {
+				t.Errorf("LoadError.Rows[%d] = %v, want line %d: %v", i, got, want.line, want.reason)
+			}
+			if got.Text != strings.Split(data, "\n")[want.line-1] {
+				t.Errorf("LoadError.Rows[%d].Text = %q, want the raw row", i, got.Text)
+			}
+		}
+		wantASN := []ASN{13335, 38803, 2500}
+		if len(ls) != len(wantASN) {
+			t.Fatalf("LoadFromTSVWithOptions() = %v, want %v rows", len(ls), len(wantASN))
+		}
+		for i, as := range ls {
+			if as.ASNumber != wantASN[i] {
+				t.Errorf("LoadFromTSVWithOptions()[%d].ASNumber = %v, want %v", i, as.ASNumber, wantASN[i])
+			}
+		}
+	})
+
+	t.Run("Line Too Long", func(t *testing.T) {
+		long := "1.0.0.0\t1.0.0.255\t13335\tUS\tCLOUDFLARENET\n1.0.4.0\t1.0.7.255\t38803\tAU\t" + strings.Repeat("x", 64)
+		for _, mode := range []LoadMode{LoadStrict, LoadLenient} {
+			ls, err := LoadFromTSVWithOptions(strings.NewReader(long), LoadOptions{Mode: mode, MaxLineSize: 64})
+			if !errors.Is(err, bufio.ErrTooLong) {
+				t.Errorf("LoadFromTSVWithOptions() error = %v, want %v", err, bufio.ErrTooLong)
+			}
+			if len(ls) != 1 {
+				t.Errorf("LoadFromTSVWithOptions() = %v, want 1 row before the error", len(ls))
+			}
+		}
+	})
+}
+
+func TestLoadFromTSV_Formats(t *testing.T) {
+	v4 := "1.0.0.0\t1.0.0.255\t13335\tUS\tCLOUDFLARENET\n1.0.4.0\t1.0.7.255\t38803\tAU\tWPL-AS-AP Wirefreebroadband Pty Ltd\n"
+	u32 := "16777216\t16777471\t13335\tUS\tCLOUDFLARENET\n16778240\t16779263\t38803\tAU\tWPL-AS-AP Wirefreebroadband Pty Ltd\n"
+	v6 := "2001:200::\t2001:200:5ff:ffff:ffff:ffff:ffff:ffff\t2500\tJP\tWIDE-BB WIDE Project\n"
+
+	want, err := LoadFromTSV(strings.NewReader(v4))
+	if err != nil {
+		t.Fatalf("LoadFromTSV(v4) error = %v", err)
+	}
+	for _, format := range []TSVFormat{TSVAuto, TSVU32} {
+		got, err := LoadFromTSVWithOptions(strings.NewReader(u32), LoadOptions{Format: format})
+		if err != nil || !reflect.DeepEqual(got, want) {
+			t.Errorf("LoadFromTSVWithOptions(u32, %v) = %v, %v, want %v", format, got, err, want)
+		}
+	}
+	if _, err := LoadFromTSVWithOptions(strings.NewReader(u32), LoadOptions{Format: TSVAddr}); !errors.Is(err, ErrInvalidAddress) {
+		t.Errorf("LoadFromTSVWithOptions(u32, TSVAddr) error = %v, want %v", err, ErrInvalidAddress)
+	}
+	if _, err := LoadFromTSVWithOptions(strings.NewReader(v4), LoadOptions{Format: TSVU32}); !errors.Is(err, ErrInvalidAddress) {
+		t.Errorf("LoadFromTSVWithOptions(v4, TSVU32) error = %v, want %v", err, ErrInvalidAddress)
+	}
+
+	bad := []struct {
+		name string
+		row  string
+		want error
+	}{
+		{name: "overflow", row: "16777216\t4294967296\t13335\tUS\tCLOUDFLARENET", want: ErrInvalidAddress},
+		{name: "inverted", row: "16777471\t16777216\t13335\tUS\tCLOUDFLARENET", want: ErrInvertedRange},
+		{name: "mixed", row: "16777216\t1.0.0.255\t13335\tUS\tCLOUDFLARENET", want: ErrInvalidAddress},
+	}
+	for _, tt := range bad {
+		if _, err := LoadFromTSV(strings.NewReader(tt.row)); !errors.Is(err, tt.want) {
+			t.Errorf("LoadFromTSV(%s) error = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+
+	t.Run("Merge", func(t *testing.T) {
+		v4List, err := LoadFromTSV(strings.NewReader(u32))
+		if err != nil {
+			t.Fatalf("LoadFromTSV(u32) error = %v", err)
+		}
+		v6List, err := LoadFromTSV(strings.NewReader(v6))
+		if err != nil {
+			t.Fatalf("LoadFromTSV(v6) error = %v", err)
+		}
+		combined, err := LoadFromTSV(strings.NewReader(v4 + v6))
+		if err != nil {
+			t.Fatalf("LoadFromTSV(combined) error = %v", err)
+		}
+
+		merged := MergeASLists(NewASList(v6List), NewASList(v4List))
+		if got, want := merged.zones(), NewASList(combined).zones(); !reflect.DeepEqual(got, want) {
+			t.Errorf("MergeASLists() = %v, want %v", got, want)
+		}
+		for ip, want := range map[string]ASN{"1.0.0.1": 13335, "1.0.5.1": 38803, "2001:200::1": 2500} {
+			if as, found := merged.Find(netip.MustParseAddr(ip)); !found || as.ASNumber != want {
+				t.Errorf("MergeASLists().Find(%v) = %v, %v, want %v", ip, as, found, want)
+			}
+		}
+		if got := MergeASLists(); got.IndexLen() != 0 {
+			t.Errorf("MergeASLists().IndexLen() = %v, want 0", got.IndexLen())
+		}
+	})
+}
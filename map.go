@@ -1,29 +1,74 @@
 package asndb
 
-import "sort"
+import (
+	"net/netip"
+	"sort"
+	"sync"
+)
 
 type ASNMap struct {
-	m map[int][]AS
+	m map[ASN][]AS
+	//countries maps a country code to the sorted list of ASN with zones registered to it
+	countries map[string][]ASN
+
+	//search is built on first use by Search
+	searchOnce sync.Once
+	search     *searchIndex
 }
 
 func NewASNMap(s []AS) *ASNMap {
-	m := make(map[int][]AS)
+	m := make(map[ASN][]AS)
 	for _, asn := range s {
 		m[asn.ASNumber] = append(m[asn.ASNumber], asn)
 	}
 
-	return &ASNMap{m: m}
+	countries := make(map[string][]ASN)
+	for asn, as := range m {
+		for _, cc := range countryCodes(as) {
+			countries[cc] = append(countries[cc], asn)
+		}
+	}
+	for _, asn := range countries {
+		sort.Slice(asn, func(i, j int) bool {
+			return asn[i] < asn[j]
+		})
+	}
+
+	return &ASNMap{m: m, countries: countries}
 }
 
 // ListAS returns a list of AS zones controlled by given asn.
 // The returned slice will be cloned and can be freely edited.
-func (m *ASNMap) ListAS(asn int) ([]AS, bool) {
+func (m *ASNMap) ListAS(asn ASN) ([]AS, bool) {
 	s, ok := m.m[asn]
 	return clone(s), ok
 }
 
+// Prefixes returns the aggregated list of prefixes covering all AS zones controlled by given asn.
+// Overlapping and adjacent zones are merged before being split into prefixes, IPv4 prefixes are listed first.
+func (m *ASNMap) Prefixes(asn ASN) ([]netip.Prefix, bool) {
+	s, ok := m.m[asn]
+	if !ok {
+		return nil, false
+	}
+	var g rangeGroup
+	for _, as := range s {
+		g.add(as)
+	}
+
+	var prefixes []netip.Prefix
+	for _, r := range mergeKeyRanges(g.v4) {
+		prefixes = append(prefixes, r.prefixes(32)...)
+	}
+	for _, r := range mergeKeyRanges(g.v6) {
+		prefixes = append(prefixes, r.prefixes(128)...)
+	}
+	return prefixes, true
+}
+
 // ListASN returns a list of ASN.
-// Behaviour of AS's details are undefined if details are inconsistent.
+// Behaviour of AS's details are undefined if details are inconsistent,
+// use Countries to get every country code of an ASN.
 // AS.StartIP and AS.EndIP will not be defined.
 func (m *ASNMap) ListASN() []AS {
 	s := make([]AS, 0, len(m.m))
@@ -41,6 +86,71 @@ func (m *ASNMap) ListASN() []AS {
 	return s
 }
 
+// Countries returns the sorted list of country codes the AS zones of given asn are registered to.
+func (m *ASNMap) Countries(asn ASN) ([]string, bool) {
+	s, ok := m.m[asn]
+	if !ok {
+		return nil, false
+	}
+	return countryCodes(s), true
+}
+
+// CountryASN returns the sorted list of ASN with AS zones registered to given country code.
+// An ASN spanning multiple countries is listed under each of them.
+func (m *ASNMap) CountryASN(cc string) ([]ASN, bool) {
+	s, ok := m.countries[cc]
+	return clone(s), ok
+}
+
+// CountryAS returns a list of AS zones registered to given country code, grouped by ASN.
+func (m *ASNMap) CountryAS(cc string) ([]AS, bool) {
+	asn, ok := m.countries[cc]
+	if !ok {
+		return nil, false
+	}
+	var s []AS
+	for _, n := range asn {
+		for _, as := range m.m[n] {
+			if as.CountryCode == cc {
+				s = append(s, as)
+			}
+		}
+	}
+	return s, true
+}
+
+// CountryCount is a country code with the number of ASN registered to it.
+type CountryCount struct {
+	CountryCode string
+	ASNCount    int
+}
+
+// ListCountries returns every country code with the number of ASN registered to it, sorted by country code.
+func (m *ASNMap) ListCountries() []CountryCount {
+	s := make([]CountryCount, 0, len(m.countries))
+	for cc, asn := range m.countries {
+		s = append(s, CountryCount{CountryCode: cc, ASNCount: len(asn)})
+	}
+	sort.Slice(s, func(i, j int) bool {
+		return s[i].CountryCode < s[j].CountryCode
+	})
+	return s
+}
+
+// countryCodes returns the sorted distinct country codes of the given AS zones.
+func countryCodes(s []AS) []string {
+	var codes []string
+	seen := make(map[string]bool)
+	for _, as := range s {
+		if !seen[as.CountryCode] {
+			seen[as.CountryCode] = true
+			codes = append(codes, as.CountryCode)
+		}
+	}
+	sort.Strings(codes)
+	return codes
+}
+
 type asSortASN []AS
 
 func (a asSortASN) Len() int {
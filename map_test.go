@@ -1,13 +1,14 @@
 package asndb
 
 import (
+	"net/netip"
 	"reflect"
 	"testing"
 )
 
 func TestASNMap_Integration(t *testing.T) {
 	type wantASList struct {
-		asn       int
+		asn       ASN
 		wantCount int
 		wantFound bool
 	}
@@ -48,7 +49,7 @@ func TestASNMap_Integration(t *testing.T) {
 				},
 			},
 			wantASList: []wantASList{
-				{asn: -1, wantFound: false},
+				{asn: 999999, wantFound: false},
 				{asn: 0, wantCount: 1, wantFound: true},
 				{asn: 1, wantCount: 1, wantFound: true},
 				{asn: 2, wantCount: 3, wantFound: true},
@@ -103,3 +104,111 @@ func TestASNMap_Integration(t *testing.T) {
 		})
 	}
 }
+
+func TestASNMap_Prefixes(t *testing.T) {
+	m := NewASNMap([]AS{
+		{ASNumber: 1, StartIP: netip.MustParseAddr("2001:db8:1::"), EndIP: netip.MustParseAddr("2001:db8:1:ffff:ffff:ffff:ffff:ffff")},
+		{ASNumber: 1, StartIP: netip.MustParseAddr("10.0.1.0"), EndIP: netip.MustParseAddr("10.0.1.255")},
+		{ASNumber: 1, StartIP: netip.MustParseAddr("10.0.0.0"), EndIP: netip.MustParseAddr("10.0.0.255")},
+		{ASNumber: 1, StartIP: netip.MustParseAddr("10.0.0.128"), EndIP: netip.MustParseAddr("10.0.0.200")},
+		{ASNumber: 1, StartIP: netip.MustParseAddr("10.0.2.0"), EndIP: netip.MustParseAddr("10.0.2.127")},
+		{ASNumber: 1, StartIP: netip.MustParseAddr("10.0.2.0"), EndIP: netip.MustParseAddr("10.0.2.127")},
+		{ASNumber: 1, StartIP: netip.MustParseAddr("10.0.4.0"), EndIP: netip.MustParseAddr("10.0.4.255")},
+		{ASNumber: 1, StartIP: netip.MustParseAddr("2001:db8::"), EndIP: netip.MustParseAddr("2001:db8::ffff:ffff:ffff:ffff:ffff")},
+		{ASNumber: 2, StartIP: netip.MustParseAddr("10.0.3.0"), EndIP: netip.MustParseAddr("10.0.3.255")},
+		{ASNumber: 3},
+	})
+
+	tests := []struct {
+		asn       ASN
+		want      []string
+		wantFound bool
+	}{
+		{
+			asn:       1,
+			want:      []string{"10.0.0.0/23", "10.0.2.0/25", "10.0.4.0/24", "2001:db8::/47"},
+			wantFound: true,
+		},
+		{asn: 2, want: []string{"10.0.3.0/24"}, wantFound: true},
+		{asn: 3, wantFound: true},
+		{asn: 4},
+	}
+	for _, tt := range tests {
+		prefixes, found := m.Prefixes(tt.asn)
+		var got []string
+		for _, p := range prefixes {
+			got = append(got, p.String())
+		}
+		if !reflect.DeepEqual(got, tt.want) || found != tt.wantFound {
+			t.Errorf("ASNMap.Prefixes(%v) = %v, %v, want %v, %v", tt.asn, got, found, tt.want, tt.wantFound)
+		}
+	}
+}
+
+func TestASNMap_Countries(t *testing.T) {
+	m := NewASNMap([]AS{
+		{ASNumber: 1, CountryCode: "US"},
+		{ASNumber: 1, CountryCode: "JP"},
+		{ASNumber: 1, CountryCode: "US"},
+		{ASNumber: 2, CountryCode: "US"},
+		{ASNumber: 3, CountryCode: "None"},
+		{ASNumber: 0, CountryCode: "JP"},
+	})
+
+	countries := []struct {
+		asn       ASN
+		want      []string
+		wantFound bool
+	}{
+		{asn: 1, want: []string{"JP", "US"}, wantFound: true},
+		{asn: 2, want: []string{"US"}, wantFound: true},
+		{asn: 3, want: []string{"None"}, wantFound: true},
+		{asn: 4},
+	}
+	for _, tt := range countries {
+		got, found := m.Countries(tt.asn)
+		if !reflect.DeepEqual(got, tt.want) || found != tt.wantFound {
+			t.Errorf("ASNMap.Countries(%d) = %v, %v, want %v, %v", tt.asn, got, found, tt.want, tt.wantFound)
+		}
+	}
+
+	asn := []struct {
+		cc        string
+		want      []ASN
+		wantZones int
+		wantFound bool
+	}{
+		{cc: "US", want: []ASN{1, 2}, wantZones: 3, wantFound: true},
+		{cc: "JP", want: []ASN{0, 1}, wantZones: 2, wantFound: true},
+		{cc: "None", want: []ASN{3}, wantZones: 1, wantFound: true},
+		{cc: "us"},
+	}
+	for _, tt := range asn {
+		got, found := m.CountryASN(tt.cc)
+		if !reflect.DeepEqual(got, tt.want) || found != tt.wantFound {
+			t.Errorf("ASNMap.CountryASN(%q) = %v, %v, want %v, %v", tt.cc, got, found, tt.want, tt.wantFound)
+		}
+		zones, found := m.CountryAS(tt.cc)
+		if len(zones) != tt.wantZones || found != tt.wantFound {
+			t.Errorf("ASNMap.CountryAS(%q) = %v, %v, want %d zones, %v", tt.cc, zones, found, tt.wantZones, tt.wantFound)
+		}
+		for _, as := range zones {
+			if as.CountryCode != tt.cc {
+				t.Errorf("ASNMap.CountryAS(%q) returned %v", tt.cc, as)
+			}
+		}
+	}
+
+	//the returned slice must not alias the index
+	if s, _ := m.CountryASN("US"); len(s) > 0 {
+		s[0] = 99
+		if again, _ := m.CountryASN("US"); again[0] != 1 {
+			t.Errorf("ASNMap.CountryASN() returned a shared slice")
+		}
+	}
+
+	want := []CountryCount{{CountryCode: "JP", ASNCount: 2}, {CountryCode: "None", ASNCount: 1}, {CountryCode: "US", ASNCount: 2}}
+	if got := m.ListCountries(); !reflect.DeepEqual(got, want) {
+		t.Errorf("ASNMap.ListCountries() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,112 @@
+package asndb
+
+import (
+	"net/netip"
+	"sort"
+)
+
+// MappedASList is a read-only ASList backed by a memory-mapped snapshot file written by WriteSnapshot.
+// Lookups are performed directly against the mapped file,
+// so processes mapping the same file share a single page cache copy of it.
+// Only the returned AS zones are allocated.
+type MappedASList struct {
+	v     snapshotView
+	unmap func() error
+}
+
+// OpenMappedASList maps the snapshot file at path, verifying its checksum.
+// On platforms without mmap support the file is read into memory instead.
+func OpenMappedASList(path string) (*MappedASList, error) {
+	b, unmap, err := mapFile(path)
+	if err != nil {
+		return nil, err
+	}
+	v, err := parseSnapshot(b)
+	if err == nil {
+		err = v.verify()
+	}
+	if err != nil {
+		unmap()
+		return nil, err
+	}
+	return &MappedASList{v: v, unmap: unmap}, nil
+}
+
+// Close unmaps the file, afterwards the list behaves as if it were empty.
+// Close must not be called concurrently with lookups.
+func (r *MappedASList) Close() error {
+	if r.unmap == nil {
+		return nil
+	}
+	err := r.unmap()
+	r.v = snapshotView{}
+	r.unmap = nil
+	return err
+}
+
+// Find finds and returns the AS zone for a given IP address, see ASList.Find.
+func (r *MappedASList) Find(ip netip.Addr) (AS, bool) {
+	index := r.Index(ip)
+	if index < 0 {
+		return AS{}, false
+	}
+
+	as := r.at(index)
+	if !as.Contains(ip) {
+		return AS{}, false
+	}
+	return as, true
+}
+
+// FindList attempts to find and return neighbouring AS that contain given ip address, see ASList.FindList.
+func (r *MappedASList) FindList(ip netip.Addr, search uint) []AS {
+	index := r.Index(ip)
+	var s []AS
+
+	for i := 0; i <= int(search); i++ {
+		ix := index - i
+		if ix < 0 {
+			break
+		}
+		if as := r.at(ix); as.Contains(ip) {
+			s = append(s, as)
+			search++
+		}
+	}
+
+	return s
+}
+
+// Index returns an index closest to AS zone for a given IP address, see ASList.Index.
+func (r *MappedASList) Index(ip netip.Addr) int {
+	index := sort.Search(r.v.zoneCount,
+		func(i int) bool {
+			return ip.Less(r.v.startIP(i))
+		})
+	index--
+	if index < 0 || index >= r.v.zoneCount {
+		return -1
+	}
+
+	return index
+}
+
+// FromIndex returns an AS zone at a given index.
+// Returns false if the index is out of bounds.
+func (r *MappedASList) FromIndex(i int) (AS, bool) {
+	if i < 0 || i >= r.v.zoneCount {
+		return AS{}, false
+	}
+	return r.at(i), true
+}
+
+// IndexLen returns the length of the AS zone.
+func (r *MappedASList) IndexLen() int {
+	return r.v.zoneCount
+}
+
+func (r *MappedASList) at(i int) AS {
+	return r.v.zone(i, func(i uint32) string {
+		return string(r.v.stringBytes(i))
+	})
+}
@@ -0,0 +1,94 @@
+package asndb
+
+import (
+	"bytes"
+	"errors"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func writeSnapshotFile(t *testing.T, zones []AS) string {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := WriteSnapshot(&buf, NewSnapshot(zones)); err != nil {
+		t.Fatalf("WriteSnapshot() error = %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "asndb.snapshot")
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestMappedASList(t *testing.T) {
+	zones := append(testSnapshotZones(),
+		AS{StartIP: netip.MustParseAddr("1.0.0.0"), EndIP: netip.MustParseAddr("1.255.255.255"), ASNumber: 1},
+		AS{StartIP: netip.MustParseAddr("1.0.0.128"), EndIP: netip.MustParseAddr("1.0.0.200"), ASNumber: 2},
+	)
+	want := NewASList(zones)
+	m, err := OpenMappedASList(writeSnapshotFile(t, zones))
+	if err != nil {
+		t.Fatalf("OpenMappedASList() error = %v", err)
+	}
+	defer m.Close()
+
+	if m.IndexLen() != want.IndexLen() {
+		t.Fatalf("MappedASList.IndexLen() = %v, want %v", m.IndexLen(), want.IndexLen())
+	}
+	for i := -1; i <= want.IndexLen(); i++ {
+		gotAS, gotFound := m.FromIndex(i)
+		wantAS, wantFound := want.FromIndex(i)
+		if gotAS != wantAS || gotFound != wantFound {
+			t.Errorf("MappedASList.FromIndex(%d) = %v, %v, want %v, %v", i, gotAS, gotFound, wantAS, wantFound)
+		}
+	}
+
+	for _, ip := range []string{"0.0.0.1", "1.0.0.1", "1.0.0.130", "1.0.2.0", "1.1.1.1", "1.200.0.0", "9.9.9.9",
+		"2001:200::1", "2001:201::", "::1"} {
+		addr := netip.MustParseAddr(ip)
+		if got, want := m.Index(addr), want.Index(addr); got != want {
+			t.Errorf("MappedASList.Index(%v) = %v, want %v", addr, got, want)
+		}
+		gotAS, gotFound := m.Find(addr)
+		wantAS, wantFound := want.Find(addr)
+		if gotAS != wantAS || gotFound != wantFound {
+			t.Errorf("MappedASList.Find(%v) = %v, %v, want %v, %v", addr, gotAS, gotFound, wantAS, wantFound)
+		}
+		if got, want := m.FindList(addr, 2), want.FindList(addr, 2); !reflect.DeepEqual(got, want) {
+			t.Errorf("MappedASList.FindList(%v) = %v, want %v", addr, got, want)
+		}
+	}
+
+	if err := m.Close(); err != nil {
+		t.Errorf("MappedASList.Close() error = %v", err)
+	}
+	if as, found := m.Find(netip.MustParseAddr("1.1.1.1")); found || m.IndexLen() != 0 {
+		t.Errorf("MappedASList.Find() after Close() = %v, %v, want empty", as, found)
+	}
+}
+
+func TestOpenMappedASList_Invalid(t *testing.T) {
+	path := writeSnapshotFile(t, testSnapshotZones())
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b[snapshotHeaderSize] ^= 0xff
+	if err := os.WriteFile(path, b, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := OpenMappedASList(path); !errors.Is(err, ErrInvalidSnapshot) {
+		t.Errorf("OpenMappedASList() error = %v, want %v", err, ErrInvalidSnapshot)
+	}
+
+	empty := filepath.Join(t.TempDir(), "empty")
+	if err := os.WriteFile(empty, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := OpenMappedASList(empty); !errors.Is(err, ErrInvalidSnapshot) {
+		t.Errorf("OpenMappedASList() error = %v, want %v", err, ErrInvalidSnapshot)
+	}
+}
@@ -0,0 +1,494 @@
+package asndb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"net/netip"
+)
+
+// The MaxMind DB format is laid out as follows:
+//
+//	search tree  node count * record size * 2 / 8 bytes, a binary trie over the address bits
+//	separator    16 zero bytes
+//	data section values referenced by the search tree
+//	metadata     a marker followed by a map describing the database
+//
+// Every node holds a left (0 bit) and right (1 bit) record. A record below the node count points to another node,
+// a record equal to the node count means no data, and larger records point into the data section.
+
+// ErrInvalidMMDB is wrapped by all errors caused by malformed MaxMind DB data.
+var ErrInvalidMMDB = errors.New("invalid mmdb")
+
+var mmdbMetadataMarker = []byte("\xab\xcd\xefMaxMind.com")
+
+const (
+	mmdbDataSeparator = 16
+	//mmdbMaxDepth bounds the nesting of decoded values, which also breaks pointer cycles
+	mmdbMaxDepth = 32
+)
+
+// data section types
+const (
+	mmdbExtended = iota
+	mmdbPointer
+	mmdbString
+	mmdbDouble
+	mmdbBytes
+	mmdbUint16
+	mmdbUint32
+	mmdbMap
+	mmdbInt32
+	mmdbUint64
+	mmdbUint128
+	mmdbArray
+	mmdbContainer
+	mmdbEndMarker
+	mmdbBool
+	mmdbFloat
+)
+
+// LoadFromMMDB parses a MaxMind DB file, such as GeoLite2-ASN.mmdb, into a list of AS zones.
+// Every network of the search tree becomes a zone, adjacent networks sharing the same record are merged.
+// IPv4 networks of an IPv6 database are returned as IPv4 zones, and the IPv4-mapped and 6to4 aliases of them are skipped.
+//
+// Besides the GeoLite2-ASN fields autonomous_system_number and autonomous_system_organization,
+// records with an asn field holding a number or a string such as "AS13335", a name or as_name description,
+// and a country or country_code field, or a GeoIP2 style country map holding iso_code, are understood.
+// Networks whose record holds no ASN are skipped.
+func LoadFromMMDB(reader io.Reader) ([]AS, error) {
+	b, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	db, err := parseMMDB(b)
+	if err != nil {
+		return nil, err
+	}
+	return db.zones()
+}
+
+// mmdbMetadata holds the metadata fields needed to read a database.
+type mmdbMetadata struct {
+	nodeCount  uint32
+	recordSize int
+	ipVersion  int
+}
+
+// mmdb is a parsed MaxMind DB.
+type mmdb struct {
+	meta mmdbMetadata
+	tree []byte
+	data mmdbDecoder
+}
+
+func parseMMDB(b []byte) (*mmdb, error) {
+	at := bytes.LastIndex(b, mmdbMetadataMarker)
+	if at < 0 {
+		return nil, fmt.Errorf("%w: metadata not found", ErrInvalidMMDB)
+	}
+	v, _, err := mmdbDecoder(b[at+len(mmdbMetadataMarker):]).decode(0, 0)
+	if err != nil {
+		return nil, fmt.Errorf("metadata: %w", err)
+	}
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%w: metadata is not a map", ErrInvalidMMDB)
+	}
+	if major, _ := mmdbUint(m["binary_format_major_version"]); major != 2 {
+		return nil, fmt.Errorf("%w: unsupported format version %v", ErrInvalidMMDB, m["binary_format_major_version"])
+	}
+
+	nodeCount, ok1 := mmdbUint(m["node_count"])
+	recordSize, ok2 := mmdbUint(m["record_size"])
+	ipVersion, ok3 := mmdbUint(m["ip_version"])
+	if !ok1 || !ok2 || !ok3 || nodeCount > math.MaxUint32 {
+		return nil, fmt.Errorf("%w: missing node_count, record_size or ip_version", ErrInvalidMMDB)
+	}
+	if recordSize != 24 && recordSize != 28 && recordSize != 32 {
+		return nil, fmt.Errorf("%w: unsupported record size %d", ErrInvalidMMDB, recordSize)
+	}
+	if ipVersion != 4 && ipVersion != 6 {
+		return nil, fmt.Errorf("%w: unsupported ip version %d", ErrInvalidMMDB, ipVersion)
+	}
+
+	treeSize := nodeCount * recordSize / 4
+	if treeSize+mmdbDataSeparator > uint64(at) {
+		return nil, fmt.Errorf("%w: truncated search tree", ErrInvalidMMDB)
+	}
+	return &mmdb{
+		meta: mmdbMetadata{nodeCount: uint32(nodeCount), recordSize: int(recordSize), ipVersion: int(ipVersion)},
+		tree: b[:treeSize],
+		data: b[treeSize+mmdbDataSeparator : at],
+	}, nil
+}
+
+// record returns the left or right record of a node, which must be in bounds.
+func (db *mmdb) record(node uint32, right bool) uint32 {
+	switch db.meta.recordSize {
+	case 24:
+		b := db.tree[node*6:]
+		if right {
+			b = b[3:]
+		}
+		return uint32(b[0])<<16 | uint32(b[1])<<8 | uint32(b[2])
+	case 28:
+		b := db.tree[node*7:]
+		if right {
+			return uint32(b[3]&0x0f)<<24 | uint32(b[4])<<16 | uint32(b[5])<<8 | uint32(b[6])
+		}
+		return uint32(b[3]&0xf0)<<20 | uint32(b[0])<<16 | uint32(b[1])<<8 | uint32(b[2])
+	}
+	b := db.tree[node*8:]
+	if right {
+		b = b[4:]
+	}
+	return binary.BigEndian.Uint32(b)
+}
+
+// networks calls fn with every network holding data and the offset of its data, in ascending order.
+func (db *mmdb) networks(fn func(p netip.Prefix, offset int) error) error {
+	bitLen := 32
+	if db.meta.ipVersion == 6 {
+		bitLen = 128
+	}
+
+	//in IPv6 databases, the node holding ::/96 is the IPv4 subtree, every other record pointing to it is an alias
+	ipv4Start := uint32(math.MaxUint32)
+	if bitLen == 128 {
+		node := uint32(0)
+		for i := 0; i < 96 && node < db.meta.nodeCount; i++ {
+			node = db.record(node, false)
+		}
+		ipv4Start = node
+	}
+
+	//the stack holds records along with the depth and key of the network they cover
+	type frame struct {
+		record uint32
+		depth  int
+		key    uint128
+	}
+	visited := make([]bool, db.meta.nodeCount)
+	stack := []frame{{}}
+	for len(stack) > 0 {
+		f := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		switch {
+		case f.record > db.meta.nodeCount:
+			offset := uint64(f.record) - uint64(db.meta.nodeCount) - mmdbDataSeparator
+			if offset >= uint64(len(db.data)) {
+				return fmt.Errorf("%w: record points outside the data section", ErrInvalidMMDB)
+			}
+			if err := fn(networkPrefix(bitLen, f.key, f.depth), int(offset)); err != nil {
+				return err
+			}
+		case f.record < db.meta.nodeCount:
+			if f.depth >= bitLen {
+				return fmt.Errorf("%w: search tree deeper than %d bits", ErrInvalidMMDB, bitLen)
+			}
+			if f.record == ipv4Start && f.depth > 0 && (f.depth != 96 || f.key != (uint128{})) {
+				continue
+			}
+			//besides the aliases of the IPv4 subtree, a node reached twice would have the walk take exponentially long
+			if visited[f.record] {
+				return fmt.Errorf("%w: node %d reached by multiple paths", ErrInvalidMMDB, f.record)
+			}
+			visited[f.record] = true
+			//push the right record first, so the left record is handled first
+			right := frame{record: db.record(f.record, true), depth: f.depth + 1, key: f.key.or(bitAt(bitLen, f.depth))}
+			left := frame{record: db.record(f.record, false), depth: f.depth + 1, key: f.key}
+			stack = append(stack, right, left)
+		}
+	}
+	return nil
+}
+
+// bitAt returns a key with only the bit at the given depth set, counting from the most significant bit.
+func bitAt(bitLen, depth int) uint128 {
+	if bitLen == 32 {
+		return uint128{lo: 1 << (31 - depth)}
+	}
+	if depth < 64 {
+		return uint128{hi: 1 << (63 - depth)}
+	}
+	return uint128{lo: 1 << (127 - depth)}
+}
+
+// networkPrefix converts a key and prefix length into a prefix, IPv4 networks of IPv6 databases become IPv4 prefixes.
+func networkPrefix(bitLen int, key uint128, bits int) netip.Prefix {
+	if bitLen == 32 {
+		return netip.PrefixFrom(addr4(uint32(key.lo)), bits)
+	}
+	if key.hi == 0 && key.lo>>32 == 0 && bits >= 96 {
+		return netip.PrefixFrom(addr4(uint32(key.lo)), bits-96)
+	}
+	return netip.PrefixFrom(key.addr(), bits)
+}
+
+// zones converts every network into an AS zone.
+func (db *mmdb) zones() ([]AS, error) {
+	cache := make(map[int]*AS)
+	var s []AS
+	var last int
+	err := db.networks(func(p netip.Prefix, offset int) error {
+		as, ok := cache[offset]
+		if !ok {
+			v, _, err := db.data.decode(offset, 0)
+			if err != nil {
+				return err
+			}
+			if record, isAS := mmdbAS(v); isAS {
+				as = &record
+			}
+			cache[offset] = as
+		}
+		if as == nil {
+			return nil
+		}
+
+		start, end := prefixRange(p)
+		//merge adjacent networks sharing a record
+		if n := len(s); n > 0 && last == offset && s[n-1].EndIP.Is4() == end.Is4() && s[n-1].EndIP.Next() == start {
+			s[n-1].EndIP = end
+			return nil
+		}
+		z := *as
+		z.StartIP, z.EndIP = start, end
+		s = append(s, z)
+		last = offset
+		return nil
+	})
+	return s, err
+}
+
+// mmdbAS converts a decoded record into an AS, reporting false if it has no ASN.
+func mmdbAS(v interface{}) (AS, bool) {
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return AS{}, false
+	}
+	var as AS
+	asn, found := mmdbASN(m["autonomous_system_number"])
+	if !found {
+		asn, found = mmdbASN(m["asn"])
+	}
+	if !found {
+		return AS{}, false
+	}
+	as.ASNumber = asn
+	for _, key := range []string{"autonomous_system_organization", "as_name", "name"} {
+		if s, ok := m[key].(string); ok {
+			as.ASDescription = s
+			break
+		}
+	}
+	switch country := m["country"].(type) {
+	case string:
+		as.CountryCode = country
+	case map[string]interface{}:
+		as.CountryCode, _ = country["iso_code"].(string)
+	}
+	if as.CountryCode == "" {
+		as.CountryCode, _ = m["country_code"].(string)
+	}
+	return as, true
+}
+
+func mmdbASN(v interface{}) (ASN, bool) {
+	if s, ok := v.(string); ok {
+		asn, err := ParseASN(s)
+		return asn, err == nil
+	}
+	n, ok := mmdbUint(v)
+	if !ok || n > math.MaxUint32 {
+		return 0, false
+	}
+	return ASN(n), true
+}
+
+// mmdbUint converts any decoded unsigned integer into an uint64.
+func mmdbUint(v interface{}) (uint64, bool) {
+	switch n := v.(type) {
+	case uint16:
+		return uint64(n), true
+	case uint32:
+		return uint64(n), true
+	case uint64:
+		return n, true
+	case *big.Int:
+		if n.IsUint64() {
+			return n.Uint64(), true
+		}
+	}
+	return 0, false
+}
+
+// mmdbDecoder decodes values of a data section, offsets and pointers are relative to its start.
+type mmdbDecoder []byte
+
+// decode decodes the value at offset, it returns the value and the offset after it.
+// Values are decoded as string, float64, []byte, uint16, uint32, uint64, *big.Int, int32, bool, float32,
+// map[string]interface{} and []interface{}.
+func (d mmdbDecoder) decode(offset, depth int) (interface{}, int, error) {
+	if depth > mmdbMaxDepth {
+		return nil, 0, fmt.Errorf("%w: values nested too deep", ErrInvalidMMDB)
+	}
+	typ, size, offset, err := d.control(offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	//every map and array entry takes at least one byte, bound their size before allocating
+	if (typ == mmdbMap || typ == mmdbArray) && size > len(d)-offset {
+		return nil, 0, fmt.Errorf("%w: %d entries at %d out of bounds", ErrInvalidMMDB, size, offset)
+	}
+
+	switch typ {
+	case mmdbPointer:
+		//a pointer is followed by the value it points to, but decoding continues after the pointer itself
+		v, _, err := d.decode(size, depth+1)
+		return v, offset, err
+	case mmdbMap:
+		m := make(map[string]interface{}, size)
+		for i := 0; i < size; i++ {
+			var k, v interface{}
+			k, offset, err = d.decode(offset, depth+1)
+			if err != nil {
+				return nil, 0, err
+			}
+			key, ok := k.(string)
+			if !ok {
+				return nil, 0, fmt.Errorf("%w: map key is not a string", ErrInvalidMMDB)
+			}
+			v, offset, err = d.decode(offset, depth+1)
+			if err != nil {
+				return nil, 0, err
+			}
+			m[key] = v
+		}
+		return m, offset, nil
+	case mmdbArray:
+		a := make([]interface{}, 0, size)
+		for i := 0; i < size; i++ {
+			var v interface{}
+			v, offset, err = d.decode(offset, depth+1)
+			if err != nil {
+				return nil, 0, err
+			}
+			a = append(a, v)
+		}
+		return a, offset, nil
+	case mmdbBool:
+		if size > 1 {
+			return nil, 0, fmt.Errorf("%w: invalid boolean size %d", ErrInvalidMMDB, size)
+		}
+		return size == 1, offset, nil
+	}
+
+	if size > len(d)-offset {
+		return nil, 0, fmt.Errorf("%w: value at %d out of bounds", ErrInvalidMMDB, offset)
+	}
+	b := d[offset : offset+size]
+	end := offset + size
+	switch typ {
+	case mmdbString:
+		return string(b), end, nil
+	case mmdbBytes:
+		return append([]byte(nil), b...), end, nil
+	case mmdbDouble:
+		if size != 8 {
+			return nil, 0, fmt.Errorf("%w: invalid double size %d", ErrInvalidMMDB, size)
+		}
+		return math.Float64frombits(binary.BigEndian.Uint64(b)), end, nil
+	case mmdbFloat:
+		if size != 4 {
+			return nil, 0, fmt.Errorf("%w: invalid float size %d", ErrInvalidMMDB, size)
+		}
+		return math.Float32frombits(binary.BigEndian.Uint32(b)), end, nil
+	case mmdbUint16, mmdbUint32, mmdbUint64, mmdbInt32:
+		limit := map[int]int{mmdbUint16: 2, mmdbUint32: 4, mmdbUint64: 8, mmdbInt32: 4}[typ]
+		if size > limit {
+			return nil, 0, fmt.Errorf("%w: invalid integer size %d", ErrInvalidMMDB, size)
+		}
+		var n uint64
+		for _, c := range b {
+			n = n<<8 | uint64(c)
+		}
+		switch typ {
+		case mmdbUint16:
+			return uint16(n), end, nil
+		case mmdbUint32:
+			return uint32(n), end, nil
+		case mmdbInt32:
+			return int32(uint32(n)), end, nil
+		}
+		return n, end, nil
+	case mmdbUint128:
+		if size > 16 {
+			return nil, 0, fmt.Errorf("%w: invalid integer size %d", ErrInvalidMMDB, size)
+		}
+		return new(big.Int).SetBytes(b), end, nil
+	}
+	return nil, 0, fmt.Errorf("%w: unsupported type %d", ErrInvalidMMDB, typ)
+}
+
+// control decodes the control byte at offset, returning the type, the size or pointer target, and the payload offset.
+func (d mmdbDecoder) control(offset int) (typ, size, next int, err error) {
+	if offset < 0 || offset >= len(d) {
+		return 0, 0, 0, fmt.Errorf("%w: offset %d out of bounds", ErrInvalidMMDB, offset)
+	}
+	c := d[offset]
+	offset++
+	typ = int(c >> 5)
+
+	if typ == mmdbPointer {
+		n := int(c>>3) & 0x3
+		if offset+n+1 > len(d) {
+			return 0, 0, 0, fmt.Errorf("%w: pointer at %d out of bounds", ErrInvalidMMDB, offset)
+		}
+		b := d[offset : offset+n+1]
+		var p int
+		switch n {
+		case 0:
+			p = int(c&0x7)<<8 | int(b[0])
+		case 1:
+			p = (int(c&0x7)<<16 | int(b[0])<<8 | int(b[1])) + 2048
+		case 2:
+			p = (int(c&0x7)<<24 | int(b[0])<<16 | int(b[1])<<8 | int(b[2])) + 526336
+		case 3:
+			p = int(binary.BigEndian.Uint32(b))
+		}
+		return typ, p, offset + n + 1, nil
+	}
+
+	if typ == mmdbExtended {
+		if offset >= len(d) {
+			return 0, 0, 0, fmt.Errorf("%w: extended type at %d out of bounds", ErrInvalidMMDB, offset)
+		}
+		typ = 7 + int(d[offset])
+		offset++
+		if typ <= mmdbMap || typ > mmdbFloat {
+			return 0, 0, 0, fmt.Errorf("%w: invalid extended type %d", ErrInvalidMMDB, typ)
+		}
+	}
+
+	size = int(c & 0x1f)
+	if size >= 29 {
+		n := size - 28
+		if offset+n > len(d) {
+			return 0, 0, 0, fmt.Errorf("%w: size at %d out of bounds", ErrInvalidMMDB, offset)
+		}
+		var extra int
+		for _, b := range d[offset : offset+n] {
+			extra = extra<<8 | int(b)
+		}
+		size = []int{29, 285, 65821}[n-1] + extra
+		offset += n
+	}
+	return typ, size, offset, nil
+}
@@ -0,0 +1,541 @@
+package asndb
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"math/big"
+	"net/netip"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// mmdbFixture describes a small MaxMind DB, built by encode for the tests.
+type mmdbFixture struct {
+	ipVersion  int
+	recordSize int
+	networks   []mmdbFixtureNetwork
+	//aliases adds the IPv4-mapped and 6to4 aliases of the IPv4 subtree to IPv6 databases
+	aliases bool
+}
+
+type mmdbFixtureNetwork struct {
+	prefix string
+	//record is the index into records, networks sharing a record share its data
+	record int
+}
+
+// mmdbPointerTo is encoded as a pointer to the given data section offset.
+type mmdbPointerTo int
+
+func (f mmdbFixture) encode(t *testing.T, records []interface{}) []byte {
+	t.Helper()
+	var data []byte
+	offsets := make([]int, len(records))
+	for i, r := range records {
+		offsets[i] = len(data)
+		data = mmdbEncode(data, r)
+	}
+
+	bitLen := 32
+	if f.ipVersion == 6 {
+		bitLen = 128
+	}
+	nodes := [][2]int{{mmdbFixtureEmpty, mmdbFixtureEmpty}}
+	walk := func(key uint128, bits int) (node int, right int) {
+		for depth := 0; depth < bits-1; depth++ {
+			bit := 0
+			if key.and(bitAt(bitLen, depth)) != (uint128{}) {
+				bit = 1
+			}
+			next := nodes[node][bit]
+			if next < 0 {
+				next = len(nodes)
+				nodes = append(nodes, [2]int{mmdbFixtureEmpty, mmdbFixtureEmpty})
+				nodes[node][bit] = next
+			}
+			node = next
+		}
+		if key.and(bitAt(bitLen, bits-1)) != (uint128{}) {
+			right = 1
+		}
+		return node, right
+	}
+	for _, n := range f.networks {
+		p := netip.MustParsePrefix(n.prefix)
+		key, bits := key6(p.Addr()), p.Bits()
+		if p.Addr().Is4() {
+			key = uint128{lo: uint64(key4(p.Addr()))}
+			if bitLen == 128 {
+				bits += 96
+			}
+		}
+		node, right := walk(key, bits)
+		nodes[node][right] = -2 - offsets[n.record]
+	}
+	if f.aliases {
+		ipv4, _ := walk(uint128{}, 97)
+		for _, alias := range []string{"::ffff:0:0/96", "2002::/16"} {
+			p := netip.MustParsePrefix(alias)
+			node, right := walk(key6(p.Addr()), p.Bits())
+			nodes[node][right] = ipv4
+		}
+	}
+
+	nodeCount := len(nodes)
+	tree := mmdbFixtureTree(nodes, f.recordSize)
+	b := append(tree, make([]byte, mmdbDataSeparator)...)
+	b = append(b, data...)
+	b = append(b, mmdbMetadataMarker...)
+	return mmdbEncode(b, map[string]interface{}{
+		"node_count":                  uint32(nodeCount),
+		"record_size":                 uint16(f.recordSize),
+		"ip_version":                  uint16(f.ipVersion),
+		"database_type":               "GeoLite2-ASN",
+		"languages":                   []interface{}{},
+		"binary_format_major_version": uint16(2),
+		"binary_format_minor_version": uint16(0),
+		"build_epoch":                 uint64(1700000000),
+		"description":                 map[string]interface{}{"en": "test database"},
+	})
+}
+
+// mmdbFixtureEmpty marks a fixture tree record without data,
+// other records hold node ids, or data offsets as -2-offset.
+const mmdbFixtureEmpty = -1
+
+// mmdbFixtureTree encodes the nodes of a fixture search tree.
+func mmdbFixtureTree(nodes [][2]int, recordSize int) []byte {
+	nodeCount := len(nodes)
+	var tree []byte
+	for _, n := range nodes {
+		var rec [2]uint32
+		for i, v := range n {
+			switch {
+			case v == mmdbFixtureEmpty:
+				rec[i] = uint32(nodeCount)
+			case v >= 0:
+				rec[i] = uint32(v)
+			default:
+				rec[i] = uint32(nodeCount + mmdbDataSeparator - 2 - v)
+			}
+		}
+		switch recordSize {
+		case 24:
+			tree = append(tree, byte(rec[0]>>16), byte(rec[0]>>8), byte(rec[0]),
+				byte(rec[1]>>16), byte(rec[1]>>8), byte(rec[1]))
+		case 28:
+			tree = append(tree, byte(rec[0]>>16), byte(rec[0]>>8), byte(rec[0]),
+				byte(rec[0]>>20&0xf0|rec[1]>>24&0x0f), byte(rec[1]>>16), byte(rec[1]>>8), byte(rec[1]))
+		default:
+			tree = appendUint32(tree, rec[0])
+			tree = appendUint32(tree, rec[1])
+		}
+	}
+	return tree
+}
+
+func (u uint128) and(v uint128) uint128 {
+	return uint128{hi: u.hi & v.hi, lo: u.lo & v.lo}
+}
+
+// mmdbEncode appends the data section encoding of v to b.
+func mmdbEncode(b []byte, v interface{}) []byte {
+	uintBytes := func(n uint64) []byte {
+		var s []byte
+		for ; n > 0; n >>= 8 {
+			s = append([]byte{byte(n)}, s...)
+		}
+		return s
+	}
+	switch v := v.(type) {
+	case mmdbPointerTo:
+		switch {
+		case v < 2048:
+			return append(b, byte(mmdbPointer<<5|int(v)>>8), byte(v))
+		case v < 526336:
+			p := int(v) - 2048
+			return append(b, byte(mmdbPointer<<5|1<<3|p>>16), byte(p>>8), byte(p))
+		}
+		return appendUint32(append(b, mmdbPointer<<5|3<<3), uint32(v))
+	case string:
+		return append(mmdbControl(b, mmdbString, len(v)), v...)
+	case []byte:
+		return append(mmdbControl(b, mmdbBytes, len(v)), v...)
+	case float64:
+		return appendUint64(mmdbControl(b, mmdbDouble, 8), math.Float64bits(v))
+	case float32:
+		return appendUint32(mmdbControl(b, mmdbFloat, 4), math.Float32bits(v))
+	case bool:
+		if v {
+			return mmdbControl(b, mmdbBool, 1)
+		}
+		return mmdbControl(b, mmdbBool, 0)
+	case uint16:
+		s := uintBytes(uint64(v))
+		return append(mmdbControl(b, mmdbUint16, len(s)), s...)
+	case uint32:
+		s := uintBytes(uint64(v))
+		return append(mmdbControl(b, mmdbUint32, len(s)), s...)
+	case uint64:
+		s := uintBytes(v)
+		return append(mmdbControl(b, mmdbUint64, len(s)), s...)
+	case int32:
+		s := uintBytes(uint64(uint32(v)))
+		return append(mmdbControl(b, mmdbInt32, len(s)), s...)
+	case *big.Int:
+		s := v.Bytes()
+		return append(mmdbControl(b, mmdbUint128, len(s)), s...)
+	case []interface{}:
+		b = mmdbControl(b, mmdbArray, len(v))
+		for _, e := range v {
+			b = mmdbEncode(b, e)
+		}
+		return b
+	case map[string]interface{}:
+		b = mmdbControl(b, mmdbMap, len(v))
+		for k, e := range v {
+			b = mmdbEncode(mmdbEncode(b, k), e)
+		}
+		return b
+	case mmdbKeyValues:
+		b = mmdbControl(b, mmdbMap, len(v)/2)
+		for _, e := range v {
+			b = mmdbEncode(b, e)
+		}
+		return b
+	}
+	panic("unsupported mmdb test value")
+}
+
+func appendUint32(b []byte, v uint32) []byte {
+	var s [4]byte
+	binary.BigEndian.PutUint32(s[:], v)
+	return append(b, s[:]...)
+}
+
+func appendUint64(b []byte, v uint64) []byte {
+	var s [8]byte
+	binary.BigEndian.PutUint64(s[:], v)
+	return append(b, s[:]...)
+}
+
+// mmdbKeyValues is encoded as a map of alternating keys and values, allowing keys to be pointers.
+type mmdbKeyValues []interface{}
+
+func mmdbControl(b []byte, typ, size int) []byte {
+	var extra []byte
+	switch {
+	case size < 29:
+	case size < 285:
+		extra, size = []byte{byte(size - 29)}, 29
+	case size < 65821:
+		extra, size = []byte{byte((size - 285) >> 8), byte(size - 285)}, 30
+	default:
+		n := size - 65821
+		extra, size = []byte{byte(n >> 16), byte(n >> 8), byte(n)}, 31
+	}
+	if typ > mmdbMap {
+		b = append(b, byte(size), byte(typ-7))
+	} else {
+		b = append(b, byte(typ<<5|size))
+	}
+	return append(b, extra...)
+}
+
+func geoLiteRecord(asn uint32, org string) map[string]interface{} {
+	return map[string]interface{}{
+		"autonomous_system_number":       asn,
+		"autonomous_system_organization": org,
+	}
+}
+
+func TestLoadFromMMDB(t *testing.T) {
+	records := []interface{}{
+		geoLiteRecord(13335, "CLOUDFLARENET"),
+		geoLiteRecord(15169, "GOOGLE"),
+		geoLiteRecord(4200000000, "PRIVATE"),
+	}
+	networks := []mmdbFixtureNetwork{
+		{prefix: "1.0.0.0/24", record: 0},
+		{prefix: "1.0.1.0/24", record: 0},
+		{prefix: "1.0.3.0/24", record: 0},
+		{prefix: "8.8.8.0/24", record: 1},
+		{prefix: "255.255.255.255/32", record: 2},
+	}
+	want := []AS{
+		{StartIP: netip.MustParseAddr("1.0.0.0"), EndIP: netip.MustParseAddr("1.0.1.255"), ASNumber: 13335, ASDescription: "CLOUDFLARENET"},
+		{StartIP: netip.MustParseAddr("1.0.3.0"), EndIP: netip.MustParseAddr("1.0.3.255"), ASNumber: 13335, ASDescription: "CLOUDFLARENET"},
+		{StartIP: netip.MustParseAddr("8.8.8.0"), EndIP: netip.MustParseAddr("8.8.8.255"), ASNumber: 15169, ASDescription: "GOOGLE"},
+		{StartIP: netip.MustParseAddr("255.255.255.255"), EndIP: netip.MustParseAddr("255.255.255.255"), ASNumber: 4200000000, ASDescription: "PRIVATE"},
+	}
+	v6 := append([]mmdbFixtureNetwork{
+		{prefix: "2606:4700::/32", record: 0},
+		{prefix: "ffff:ffff:ffff:ffff:ffff:ffff:ffff:ffff/128", record: 1},
+	}, networks...)
+	wantV6 := append(append([]AS(nil), want...),
+		AS{StartIP: netip.MustParseAddr("2606:4700::"), EndIP: netip.MustParseAddr("2606:4700:ffff:ffff:ffff:ffff:ffff:ffff"), ASNumber: 13335, ASDescription: "CLOUDFLARENET"},
+		AS{StartIP: netip.MustParseAddr("ffff:ffff:ffff:ffff:ffff:ffff:ffff:ffff"), EndIP: netip.MustParseAddr("ffff:ffff:ffff:ffff:ffff:ffff:ffff:ffff"), ASNumber: 15169, ASDescription: "GOOGLE"},
+	)
+
+	for _, recordSize := range []int{24, 28, 32} {
+		for _, tt := range []struct {
+			name string
+			f    mmdbFixture
+			want []AS
+		}{
+			{"ipv4", mmdbFixture{ipVersion: 4, recordSize: recordSize, networks: networks}, want},
+			{"ipv6", mmdbFixture{ipVersion: 6, recordSize: recordSize, networks: v6}, wantV6},
+			{"ipv6 aliases", mmdbFixture{ipVersion: 6, recordSize: recordSize, networks: v6, aliases: true}, wantV6},
+		} {
+			b := tt.f.encode(t, records)
+			got, err := LoadFromMMDB(bytes.NewReader(b))
+			if err != nil {
+				t.Fatalf("%s/%d: LoadFromMMDB() error = %v", tt.name, recordSize, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("%s/%d: LoadFromMMDB() = %v, want %v", tt.name, recordSize, got, tt.want)
+			}
+		}
+	}
+
+	//the zones are usable as an ASList
+	b := mmdbFixture{ipVersion: 6, recordSize: 28, networks: v6, aliases: true}.encode(t, records)
+	s, err := LoadFromMMDB(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	list := NewASList(s)
+	for ip, want := range map[string]ASN{"1.0.1.1": 13335, "8.8.8.8": 15169, "2606:4700::1111": 13335} {
+		if as, found := list.Find(netip.MustParseAddr(ip)); !found || as.ASNumber != want {
+			t.Errorf("ASList.Find(%s) = %v, %t, want %s", ip, as, found, want)
+		}
+	}
+}
+
+func TestLoadFromMMDB_Compatible(t *testing.T) {
+	records := []interface{}{
+		map[string]interface{}{"asn": "AS13335", "name": "Cloudflare, Inc.", "domain": "cloudflare.com"},
+		map[string]interface{}{"asn": "AS15169", "as_name": "Google LLC", "country": "US"},
+		map[string]interface{}{"autonomous_system_number": uint16(3320), "country": map[string]interface{}{"iso_code": "DE"}},
+		map[string]interface{}{"asn": uint64(2500), "country_code": "JP"},
+		map[string]interface{}{"country": "NL"},
+		"not a map",
+	}
+	f := mmdbFixture{ipVersion: 4, recordSize: 24, networks: []mmdbFixtureNetwork{
+		{prefix: "1.1.1.0/24", record: 0},
+		{prefix: "8.8.8.0/24", record: 1},
+		{prefix: "80.0.0.0/8", record: 2},
+		{prefix: "133.0.0.0/8", record: 3},
+		{prefix: "145.0.0.0/8", record: 4},
+		{prefix: "146.0.0.0/8", record: 5},
+	}}
+	got, err := LoadFromMMDB(bytes.NewReader(f.encode(t, records)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []AS{
+		{StartIP: netip.MustParseAddr("1.1.1.0"), EndIP: netip.MustParseAddr("1.1.1.255"), ASNumber: 13335, ASDescription: "Cloudflare, Inc."},
+		{StartIP: netip.MustParseAddr("8.8.8.0"), EndIP: netip.MustParseAddr("8.8.8.255"), ASNumber: 15169, CountryCode: "US", ASDescription: "Google LLC"},
+		{StartIP: netip.MustParseAddr("80.0.0.0"), EndIP: netip.MustParseAddr("80.255.255.255"), ASNumber: 3320, CountryCode: "DE"},
+		{StartIP: netip.MustParseAddr("133.0.0.0"), EndIP: netip.MustParseAddr("133.255.255.255"), ASNumber: 2500, CountryCode: "JP"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("LoadFromMMDB() = %v, want %v", got, want)
+	}
+}
+
+func TestLoadFromMMDB_Invalid(t *testing.T) {
+	records := []interface{}{geoLiteRecord(13335, "CLOUDFLARENET")}
+	valid := mmdbFixture{ipVersion: 4, recordSize: 24, networks: []mmdbFixtureNetwork{{prefix: "1.0.0.0/24"}}}.encode(t, records)
+	metadata := bytes.LastIndex(valid, mmdbMetadataMarker)
+
+	withMetadata := func(m map[string]interface{}) []byte {
+		b := append([]byte(nil), valid[:metadata]...)
+		return mmdbEncode(append(b, mmdbMetadataMarker...), m)
+	}
+	meta := func(recordSize, ipVersion, nodeCount int) map[string]interface{} {
+		return map[string]interface{}{
+			"node_count":                  uint32(nodeCount),
+			"record_size":                 uint16(recordSize),
+			"ip_version":                  uint16(ipVersion),
+			"binary_format_major_version": uint16(2),
+		}
+	}
+	//a chain of nodes pointing both records at the next node, reaching the last node by 2^59 paths
+	chain := make([][2]int, 60)
+	for i := range chain[:59] {
+		chain[i] = [2]int{i + 1, i + 1}
+	}
+	chain[59] = [2]int{mmdbFixtureEmpty, mmdbFixtureEmpty}
+	shared := append(mmdbFixtureTree(chain, 24), make([]byte, mmdbDataSeparator)...)
+	shared = mmdbEncode(append(shared, mmdbMetadataMarker...), meta(24, 6, len(chain)))
+
+	outOfBounds := append([]byte(nil), valid...)
+	//point the right record of the root past the data section
+	outOfBounds[3], outOfBounds[4], outOfBounds[5] = 0xff, 0xff, 0xff
+
+	tests := []struct {
+		name string
+		b    []byte
+	}{
+		{"empty", nil},
+		{"no metadata", valid[:metadata]},
+		{"truncated metadata", valid[:metadata+len(mmdbMetadataMarker)+3]},
+		{"metadata not a map", append(append([]byte(nil), mmdbMetadataMarker...), mmdbEncode(nil, "x")...)},
+		{"record size", withMetadata(meta(16, 4, 1))},
+		{"ip version", withMetadata(meta(24, 5, 1))},
+		{"node count", withMetadata(meta(24, 4, 1000))},
+		{"format version", withMetadata(map[string]interface{}{"node_count": uint32(1), "record_size": uint16(24), "ip_version": uint16(4)})},
+		{"record out of bounds", outOfBounds},
+		{"shared nodes", shared},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadFromMMDB(bytes.NewReader(tt.b))
+			if !errors.Is(err, ErrInvalidMMDB) {
+				t.Errorf("LoadFromMMDB() error = %v, want %v", err, ErrInvalidMMDB)
+			}
+		})
+	}
+}
+
+func TestMMDBDecoder(t *testing.T) {
+	long := strings.Repeat("x", 300)
+	huge := strings.Repeat("y", 70000)
+	u128, _ := new(big.Int).SetString("340282366920938463463374607431768211455", 10)
+
+	tests := []struct {
+		name string
+		v    interface{}
+		want interface{}
+	}{
+		{"string", "hello", "hello"},
+		{"empty string", "", ""},
+		{"long string", long, long},
+		{"huge string", huge, huge},
+		{"double", 3.5, 3.5},
+		{"float", float32(1.25), float32(1.25)},
+		{"bytes", []byte{1, 2, 3}, []byte{1, 2, 3}},
+		{"uint16", uint16(65535), uint16(65535)},
+		{"uint32 zero", uint32(0), uint32(0)},
+		{"uint32", uint32(4200000000), uint32(4200000000)},
+		{"uint64", uint64(math.MaxUint64), uint64(math.MaxUint64)},
+		{"uint128", u128, u128},
+		{"int32", int32(-42), int32(-42)},
+		{"true", true, true},
+		{"false", false, false},
+		{"array", []interface{}{"a", uint16(1), []interface{}{}}, []interface{}{"a", uint16(1), []interface{}{}}},
+		{"map", map[string]interface{}{"a": map[string]interface{}{"b": true}}, map[string]interface{}{"a": map[string]interface{}{"b": true}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := mmdbEncode(nil, tt.v)
+			got, next, err := mmdbDecoder(b).decode(0, 0)
+			if err != nil {
+				t.Fatalf("decode() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("decode() = %v, want %v", got, tt.want)
+			}
+			if next != len(b) {
+				t.Errorf("decode() next = %d, want %d", next, len(b))
+			}
+		})
+	}
+}
+
+func TestMMDBDecoder_Pointer(t *testing.T) {
+	for _, offset := range []int{0, 3000, 600000} {
+		d := make([]byte, offset)
+		d = mmdbEncode(d, "autonomous_system_number")
+		d = mmdbEncode(d, "CLOUDFLARENET")
+		start := len(d)
+		d = mmdbEncode(d, mmdbKeyValues{mmdbPointerTo(offset), uint32(13335), "org", mmdbPointerTo(offset + 25)})
+
+		got, next, err := mmdbDecoder(d).decode(start, 0)
+		if err != nil {
+			t.Fatalf("decode(%d) error = %v", offset, err)
+		}
+		want := map[string]interface{}{"autonomous_system_number": uint32(13335), "org": "CLOUDFLARENET"}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("decode(%d) = %v, want %v", offset, got, want)
+		}
+		if next != len(d) {
+			t.Errorf("decode(%d) next = %d, want %d", offset, next, len(d))
+		}
+	}
+}
+
+func TestMMDBDecoder_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		b    []byte
+	}{
+		{"empty", nil},
+		{"truncated string", []byte{mmdbString<<5 | 5, 'a'}},
+		{"truncated size", []byte{mmdbString<<5 | 30, 1}},
+		{"truncated pointer", []byte{mmdbPointer<<5 | 1<<3, 0}},
+		{"pointer out of bounds", []byte{mmdbPointer << 5, 0xff}},
+		{"pointer cycle", []byte{mmdbPointer << 5, 0}},
+		{"missing extended type", []byte{0}},
+		{"invalid extended type", []byte{1, 20}},
+		{"double size", []byte{mmdbDouble<<5 | 4, 0, 0, 0, 0}},
+		{"uint16 size", []byte{mmdbUint16<<5 | 3, 0, 0, 0}},
+		{"uint128 size", append([]byte{17, mmdbUint128 - 7}, make([]byte, 17)...)},
+		{"bool size", []byte{2, mmdbBool - 7}},
+		{"map key", mmdbEncode([]byte{mmdbMap<<5 | 1}, uint32(1))},
+		{"truncated map", []byte{mmdbMap<<5 | 1}},
+		{"map size", []byte{mmdbMap<<5 | 31, 0xff, 0xff, 0xff}},
+		{"array size", []byte{31, mmdbArray - 7, 0xff, 0xff, 0xff}},
+		{"end marker", []byte{0, mmdbEndMarker - 7}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := mmdbDecoder(tt.b).decode(0, 0)
+			if !errors.Is(err, ErrInvalidMMDB) {
+				t.Errorf("decode() error = %v, want %v", err, ErrInvalidMMDB)
+			}
+		})
+	}
+}
+
+func TestMMDB_Record(t *testing.T) {
+	tests := []struct {
+		recordSize  int
+		tree        []byte
+		left, right uint32
+	}{
+		{24, []byte{0x12, 0x34, 0x56, 0x78, 0x9a, 0xbc}, 0x123456, 0x789abc},
+		{28, []byte{0x12, 0x34, 0x56, 0xab, 0x78, 0x9a, 0xbc}, 0xa123456, 0xb789abc},
+		{32, []byte{0x12, 0x34, 0x56, 0x78, 0x9a, 0xbc, 0xde, 0xf0}, 0x12345678, 0x9abcdef0},
+	}
+	for _, tt := range tests {
+		db := &mmdb{meta: mmdbMetadata{nodeCount: 1, recordSize: tt.recordSize}, tree: tt.tree}
+		if got := db.record(0, false); got != tt.left {
+			t.Errorf("%d: record(left) = %#x, want %#x", tt.recordSize, got, tt.left)
+		}
+		if got := db.record(0, true); got != tt.right {
+			t.Errorf("%d: record(right) = %#x, want %#x", tt.recordSize, got, tt.right)
+		}
+	}
+}
+
+func TestMMDBSource(t *testing.T) {
+	b := mmdbFixture{ipVersion: 4, recordSize: 24, networks: []mmdbFixtureNetwork{{prefix: "1.1.1.0/24"}}}.
+		encode(t, []interface{}{geoLiteRecord(13335, "CLOUDFLARENET")})
+	r := NewRegistry(nil)
+	err := r.Reload(context.Background(), MMDBSource(func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(b)), nil
+	}))
+	if err != nil {
+		t.Fatalf("Registry.Reload() error = %v", err)
+	}
+	if as, found := r.Find(netip.MustParseAddr("1.1.1.1")); !found || as.ASNumber != 13335 {
+		t.Errorf("Registry.Find() = %v, %v, want 13335, true", as.ASNumber, found)
+	}
+}
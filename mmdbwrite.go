@@ -0,0 +1,361 @@
+package asndb
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/netip"
+	"sort"
+	"time"
+)
+
+// MMDBOptions configures the MaxMind DB written by WriteMMDB.
+type MMDBOptions struct {
+	// IPVersion is 4 or 6, 6 by default. IPv4 databases can not hold IPv6 zones.
+	IPVersion int
+	// DatabaseType is stored in the metadata, "GeoLite2-ASN" by default so tools expecting an ASN database accept it.
+	DatabaseType string
+	// Description is stored as the English description in the metadata.
+	Description string
+	// BuildTime is stored as the build epoch, the current time by default.
+	BuildTime time.Time
+	// DisableIPv4Aliases skips aliasing ::ffff:0:0/96 and 2002::/16 to the IPv4 networks of IPv6 databases.
+	DisableIPv4Aliases bool
+}
+
+// mmdbEmpty marks a record without data in the search tree being written,
+// other records hold a node index, or a data section offset stored as mmdbData(offset).
+const mmdbEmpty = -1
+
+func mmdbData(offset int) int {
+	return -2 - offset
+}
+
+// WriteMMDB writes the AS zones as a MaxMind DB, which can be read by LoadFromMMDB and other MaxMind DB readers.
+// Every zone is split into CIDR networks, holding a GeoLite2-ASN style record of autonomous_system_number
+// and autonomous_system_organization, along with a GeoIP2 style country.iso_code when the country code is known.
+// Where zones of the same family overlap, the zone with the later StartIP takes precedence.
+//
+// In IPv6 databases, IPv4 zones are stored at ::/96 and aliased from ::ffff:0:0/96 and 2002::/16,
+// so IPv4-mapped and 6to4 addresses resolve to them, unless disabled by MMDBOptions.DisableIPv4Aliases.
+// When there are IPv4 zones, they take precedence over IPv6 zones within ::/96,
+// and IPv6 zones within the aliased networks are replaced by the aliases.
+func WriteMMDB(w io.Writer, s []AS, opts MMDBOptions) error {
+	s = clone(s)
+	sort.Stable(asSortIP(s))
+	return writeMMDB(w, s, opts)
+}
+
+// WriteMMDB writes the AS zones of the list as a MaxMind DB, see WriteMMDB.
+func (r *ASList) WriteMMDB(w io.Writer, opts MMDBOptions) error {
+	return writeMMDB(w, r.zones(), opts)
+}
+
+// writeMMDB writes zones sorted by StartIP as a MaxMind DB.
+func writeMMDB(w io.Writer, s []AS, opts MMDBOptions) error {
+	if opts.IPVersion == 0 {
+		opts.IPVersion = 6
+	}
+	if opts.IPVersion != 4 && opts.IPVersion != 6 {
+		return fmt.Errorf("mmdb: unsupported ip version %d", opts.IPVersion)
+	}
+	if opts.DatabaseType == "" {
+		opts.DatabaseType = "GeoLite2-ASN"
+	}
+	if opts.BuildTime.IsZero() {
+		opts.BuildTime = time.Now()
+	}
+	bitLen := 32
+	if opts.IPVersion == 6 {
+		bitLen = 128
+	}
+
+	data := newMMDBEncoder()
+	offsets := make(map[asMeta]int)
+	t := mmdbTree{bitLen: bitLen, nodes: [][2]int{{mmdbEmpty, mmdbEmpty}}}
+	var hasIPv4 bool
+	//IPv6 zones go in first, so the IPv4 zones stored at ::/96 replace any IPv6 zone covering that space
+	for _, ipv4 := range []bool{false, true} {
+		if ipv4 && bitLen == 128 && hasIPv4 {
+			t.insert(uint128{}, 96, mmdbEmpty)
+		}
+		for _, as := range s {
+			prefixes := as.Prefixes()
+			if len(prefixes) == 0 {
+				continue
+			}
+			if as.StartIP.Is4() != ipv4 {
+				hasIPv4 = hasIPv4 || as.StartIP.Is4()
+				continue
+			}
+			if bitLen == 32 && !ipv4 {
+				return fmt.Errorf("mmdb: IPv6 zone %v in IPv4 database", as)
+			}
+
+			m := asMeta{ASNumber: as.ASNumber, CountryCode: as.CountryCode, ASDescription: as.ASDescription}
+			offset, ok := offsets[m]
+			if !ok {
+				offset = data.len()
+				offsets[m] = offset
+				data.asRecord(m)
+			}
+			for _, p := range prefixes {
+				key, bits := key6(p.Addr()), p.Bits()
+				if ipv4 {
+					key = uint128{lo: uint64(key4(p.Addr()))}
+					if bitLen == 128 {
+						bits += 96
+					}
+				}
+				t.insert(key, bits, mmdbData(offset))
+			}
+		}
+	}
+	if bitLen == 128 && hasIPv4 && !opts.DisableIPv4Aliases {
+		ipv4 := t.node(uint128{}, 96)
+		for _, alias := range []netip.Prefix{netip.MustParsePrefix("::ffff:0:0/96"), netip.MustParsePrefix("2002::/16")} {
+			t.insert(key6(alias.Addr()), alias.Bits(), ipv4)
+		}
+	}
+
+	nodes := t.compact()
+	maxRecord := uint64(len(nodes)) + mmdbDataSeparator + uint64(data.len())
+	recordSize := 32
+	switch {
+	case maxRecord < 1<<24:
+		recordSize = 24
+	case maxRecord < 1<<28:
+		recordSize = 28
+	case maxRecord > math.MaxUint32:
+		return errors.New("mmdb: database too large")
+	}
+
+	meta := newMMDBEncoder()
+	meta.control(mmdbMap, 9)
+	meta.string("node_count")
+	meta.uint(mmdbUint32, uint64(len(nodes)))
+	meta.string("record_size")
+	meta.uint(mmdbUint16, uint64(recordSize))
+	meta.string("ip_version")
+	meta.uint(mmdbUint16, uint64(opts.IPVersion))
+	meta.string("database_type")
+	meta.string(opts.DatabaseType)
+	meta.string("languages")
+	meta.control(mmdbArray, 1)
+	meta.string("en")
+	meta.string("binary_format_major_version")
+	meta.uint(mmdbUint16, 2)
+	meta.string("binary_format_minor_version")
+	meta.uint(mmdbUint16, 0)
+	meta.string("build_epoch")
+	meta.uint(mmdbUint64, uint64(opts.BuildTime.Unix()))
+	meta.string("description")
+	meta.control(mmdbMap, 1)
+	meta.string("en")
+	meta.string(opts.Description)
+
+	for _, b := range [][]byte{encodeMMDBTree(nodes, recordSize), make([]byte, mmdbDataSeparator), data.b, mmdbMetadataMarker, meta.b} {
+		if _, err := w.Write(b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mmdbTree is a search tree being written, nodes hold their left and right record.
+type mmdbTree struct {
+	bitLen int
+	nodes  [][2]int
+}
+
+// insert sets every record covered by the network key/bits to ref, replacing more specific networks.
+func (t *mmdbTree) insert(key uint128, bits int, ref int) {
+	if bits == 0 {
+		t.nodes[0] = [2]int{ref, ref}
+		return
+	}
+	node := t.node(key, bits-1)
+	t.nodes[node][t.bit(key, bits-1)] = ref
+}
+
+// node returns the node at depth along the path of key, splitting records holding data on the way.
+func (t *mmdbTree) node(key uint128, depth int) int {
+	node := 0
+	for i := 0; i < depth; i++ {
+		b := t.bit(key, i)
+		next := t.nodes[node][b]
+		if next < 0 {
+			//the new node inherits the data of the network it splits
+			next = len(t.nodes)
+			t.nodes = append(t.nodes, [2]int{t.nodes[node][b], t.nodes[node][b]})
+			t.nodes[node][b] = next
+		}
+		node = next
+	}
+	return node
+}
+
+func (t *mmdbTree) bit(key uint128, depth int) int {
+	if bit := bitAt(t.bitLen, depth); key.hi&bit.hi != 0 || key.lo&bit.lo != 0 {
+		return 1
+	}
+	return 0
+}
+
+// compact drops the nodes no longer reachable from the root, numbering the remaining nodes in breadth first order.
+func (t *mmdbTree) compact() [][2]int {
+	index := map[int]int{0: 0}
+	order := []int{0}
+	for i := 0; i < len(order); i++ {
+		for _, r := range t.nodes[order[i]] {
+			if _, seen := index[r]; r >= 0 && !seen {
+				index[r] = len(order)
+				order = append(order, r)
+			}
+		}
+	}
+	nodes := make([][2]int, len(order))
+	for i, n := range order {
+		for j, r := range t.nodes[n] {
+			if r >= 0 {
+				r = index[r]
+			}
+			nodes[i][j] = r
+		}
+	}
+	return nodes
+}
+
+// encodeMMDBTree encodes the nodes of a search tree, data records are offsets into the data section.
+func encodeMMDBTree(nodes [][2]int, recordSize int) []byte {
+	nodeCount := len(nodes)
+	b := make([]byte, 0, nodeCount*recordSize/4)
+	for _, n := range nodes {
+		var rec [2]uint32
+		for i, r := range n {
+			switch {
+			case r == mmdbEmpty:
+				rec[i] = uint32(nodeCount)
+			case r >= 0:
+				rec[i] = uint32(r)
+			default:
+				rec[i] = uint32(nodeCount + mmdbDataSeparator + mmdbData(r))
+			}
+		}
+		switch recordSize {
+		case 24:
+			b = append(b, byte(rec[0]>>16), byte(rec[0]>>8), byte(rec[0]),
+				byte(rec[1]>>16), byte(rec[1]>>8), byte(rec[1]))
+		case 28:
+			b = append(b, byte(rec[0]>>16), byte(rec[0]>>8), byte(rec[0]),
+				byte(rec[0]>>20&0xf0|rec[1]>>24&0x0f), byte(rec[1]>>16), byte(rec[1]>>8), byte(rec[1]))
+		default:
+			var s [8]byte
+			binary.BigEndian.PutUint32(s[:4], rec[0])
+			binary.BigEndian.PutUint32(s[4:], rec[1])
+			b = append(b, s[:]...)
+		}
+	}
+	return b
+}
+
+// mmdbEncoder encodes values of a data section, repeated strings are written as pointers.
+type mmdbEncoder struct {
+	b       []byte
+	strings map[string]int
+}
+
+func newMMDBEncoder() *mmdbEncoder {
+	return &mmdbEncoder{strings: make(map[string]int)}
+}
+
+func (e *mmdbEncoder) len() int {
+	return len(e.b)
+}
+
+// asRecord encodes the record of an AS zone.
+func (e *mmdbEncoder) asRecord(m asMeta) {
+	country := m.CountryCode != "" && m.CountryCode != "None"
+	n := 1
+	if m.ASDescription != "" {
+		n++
+	}
+	if country {
+		n++
+	}
+	e.control(mmdbMap, n)
+	e.string("autonomous_system_number")
+	e.uint(mmdbUint32, uint64(m.ASNumber))
+	if m.ASDescription != "" {
+		e.string("autonomous_system_organization")
+		e.string(m.ASDescription)
+	}
+	if country {
+		e.string("country")
+		e.control(mmdbMap, 1)
+		e.string("iso_code")
+		e.string(m.CountryCode)
+	}
+}
+
+func (e *mmdbEncoder) control(typ, size int) {
+	var extra []byte
+	switch {
+	case size < 29:
+	case size < 285:
+		extra, size = []byte{byte(size - 29)}, 29
+	case size < 65821:
+		extra, size = []byte{byte((size - 285) >> 8), byte(size - 285)}, 30
+	default:
+		n := size - 65821
+		extra, size = []byte{byte(n >> 16), byte(n >> 8), byte(n)}, 31
+	}
+	if typ > mmdbMap {
+		e.b = append(e.b, byte(size), byte(typ-7))
+	} else {
+		e.b = append(e.b, byte(typ<<5|size))
+	}
+	e.b = append(e.b, extra...)
+}
+
+// string encodes s, or a pointer to it if it got encoded before and the pointer is shorter.
+func (e *mmdbEncoder) string(s string) {
+	if offset, ok := e.strings[s]; ok && len(s) > 4 {
+		e.pointer(offset)
+		return
+	}
+	if _, ok := e.strings[s]; !ok {
+		e.strings[s] = len(e.b)
+	}
+	e.control(mmdbString, len(s))
+	e.b = append(e.b, s...)
+}
+
+func (e *mmdbEncoder) pointer(offset int) {
+	switch {
+	case offset < 2048:
+		e.b = append(e.b, byte(mmdbPointer<<5|offset>>8), byte(offset))
+	case offset < 526336:
+		p := offset - 2048
+		e.b = append(e.b, byte(mmdbPointer<<5|1<<3|p>>16), byte(p>>8), byte(p))
+	case offset < 134744064:
+		p := offset - 526336
+		e.b = append(e.b, byte(mmdbPointer<<5|2<<3|p>>24), byte(p>>16), byte(p>>8), byte(p))
+	default:
+		e.b = append(e.b, mmdbPointer<<5|3<<3, byte(offset>>24), byte(offset>>16), byte(offset>>8), byte(offset))
+	}
+}
+
+// uint encodes n as the unsigned integer type typ, using as few bytes as possible.
+func (e *mmdbEncoder) uint(typ int, n uint64) {
+	var s [8]byte
+	binary.BigEndian.PutUint64(s[:], n)
+	size := 8
+	for size > 0 && s[8-size] == 0 {
+		size--
+	}
+	e.control(typ, size)
+	e.b = append(e.b, s[8-size:]...)
+}
@@ -0,0 +1,320 @@
+package asndb
+
+import (
+	"bytes"
+	"net/netip"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testMMDBZones() []AS {
+	return []AS{
+		{StartIP: netip.MustParseAddr("1.0.0.0"), EndIP: netip.MustParseAddr("1.0.0.255"), ASNumber: 13335, CountryCode: "US", ASDescription: "CLOUDFLARENET"},
+		{StartIP: netip.MustParseAddr("1.0.1.0"), EndIP: netip.MustParseAddr("1.0.3.255"), ASNumber: 0, CountryCode: "None", ASDescription: "Not routed"},
+		{StartIP: netip.MustParseAddr("1.0.4.7"), EndIP: netip.MustParseAddr("1.0.9.200"), ASNumber: 38803, CountryCode: "AU", ASDescription: "GTELECOM-AUSTRALIA"},
+		{StartIP: netip.MustParseAddr("8.8.8.0"), EndIP: netip.MustParseAddr("8.8.8.255"), ASNumber: 15169, CountryCode: "US", ASDescription: "GOOGLE"},
+		{StartIP: netip.MustParseAddr("2001:200::"), EndIP: netip.MustParseAddr("2001:200:5ff:ffff:ffff:ffff:ffff:ffff"), ASNumber: 2500, CountryCode: "JP", ASDescription: "WIDE-BB WIDE Project"},
+		{StartIP: netip.MustParseAddr("2606:4700::"), EndIP: netip.MustParseAddr("2606:4700::1:5"), ASNumber: 13335, CountryCode: "US", ASDescription: "CLOUDFLARENET"},
+	}
+}
+
+// mmdbLookup walks the search tree of db for ip, returning the data offset of its network.
+func mmdbLookup(db *mmdb, ip netip.Addr) (int, bool) {
+	bitLen := 32
+	key := uint128{lo: uint64(key4(ip))}
+	if db.meta.ipVersion == 6 {
+		bitLen = 128
+		key = key6(netip.AddrFrom16(ip.As16()))
+		if ip.Is4() {
+			key = uint128{lo: key.lo & 0xffffffff}
+		}
+	}
+	node := uint32(0)
+	for depth := 0; depth < bitLen && node < db.meta.nodeCount; depth++ {
+		bit := bitAt(bitLen, depth)
+		node = db.record(node, key.hi&bit.hi != 0 || key.lo&bit.lo != 0)
+	}
+	if node <= db.meta.nodeCount {
+		return 0, false
+	}
+	return int(node - db.meta.nodeCount - mmdbDataSeparator), true
+}
+
+func TestWriteMMDB(t *testing.T) {
+	zones := testMMDBZones()
+	want := testMMDBZones()
+	//country codes are only written when known
+	want[1].CountryCode = ""
+
+	tests := []struct {
+		name  string
+		zones []AS
+		opts  MMDBOptions
+		want  []AS
+	}{
+		{"ipv6", zones, MMDBOptions{}, want},
+		{"ipv6 without aliases", zones, MMDBOptions{DisableIPv4Aliases: true}, want},
+		{"ipv4", zones[:4], MMDBOptions{IPVersion: 4}, want[:4]},
+		{"unsorted", []AS{zones[5], zones[3], zones[0]}, MMDBOptions{}, []AS{want[0], want[3], want[5]}},
+		{"empty", nil, MMDBOptions{}, nil},
+		{"invalid zones", []AS{{ASNumber: 1}, {StartIP: zones[1].EndIP, EndIP: zones[1].StartIP}}, MMDBOptions{}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := WriteMMDB(&buf, tt.zones, tt.opts); err != nil {
+				t.Fatalf("WriteMMDB() error = %v", err)
+			}
+			got, err := LoadFromMMDB(&buf)
+			if err != nil {
+				t.Fatalf("LoadFromMMDB() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("LoadFromMMDB() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteMMDB_Overlap(t *testing.T) {
+	zones := []AS{
+		{StartIP: netip.MustParseAddr("10.0.0.0"), EndIP: netip.MustParseAddr("10.0.255.255"), ASNumber: 1},
+		{StartIP: netip.MustParseAddr("10.0.1.0"), EndIP: netip.MustParseAddr("10.0.1.255"), ASNumber: 2},
+		{StartIP: netip.MustParseAddr("0.0.0.0"), EndIP: netip.MustParseAddr("255.255.255.255"), ASNumber: 3},
+	}
+	var buf bytes.Buffer
+	if err := WriteMMDB(&buf, zones, MMDBOptions{IPVersion: 4}); err != nil {
+		t.Fatal(err)
+	}
+	s, err := LoadFromMMDB(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	list := NewASList(s)
+	for ip, want := range map[string]ASN{"9.255.255.255": 3, "10.0.0.1": 1, "10.0.1.1": 2, "10.0.2.1": 1, "10.1.0.0": 3} {
+		if as, found := list.Find(netip.MustParseAddr(ip)); !found || as.ASNumber != want {
+			t.Errorf("Find(%s) = %v, %t, want %s", ip, as, found, want)
+		}
+	}
+}
+
+func TestWriteMMDB_IPv4Subtree(t *testing.T) {
+	zones := []AS{
+		{StartIP: netip.MustParseAddr("1.0.0.0"), EndIP: netip.MustParseAddr("1.0.0.255"), ASNumber: 13335, ASDescription: "CLOUDFLARENET"},
+		{StartIP: netip.MustParseAddr("::"), EndIP: netip.MustParseAddr("1fff:ffff:ffff:ffff:ffff:ffff:ffff:ffff"), ASNumber: 0, ASDescription: "Not routed"},
+	}
+	notRouted := func(start, end string) AS {
+		return AS{StartIP: netip.MustParseAddr(start), EndIP: netip.MustParseAddr(end), ASDescription: "Not routed"}
+	}
+
+	tests := []struct {
+		name string
+		opts MMDBOptions
+		want []AS
+	}{
+		{"aliases", MMDBOptions{}, []AS{
+			zones[0],
+			//::/96 holds the IPv4 zones, and ::ffff:0:0/96 is an alias of it
+			notRouted("::1:0:0", "::fffe:ffff:ffff"),
+			notRouted("::1:0:0:0", "1fff:ffff:ffff:ffff:ffff:ffff:ffff:ffff"),
+		}},
+		{"without aliases", MMDBOptions{DisableIPv4Aliases: true}, []AS{
+			zones[0],
+			notRouted("::1:0:0", "1fff:ffff:ffff:ffff:ffff:ffff:ffff:ffff"),
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := WriteMMDB(&buf, zones, tt.opts); err != nil {
+				t.Fatal(err)
+			}
+			got, err := LoadFromMMDB(&buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("LoadFromMMDB() = %v, want %v", got, tt.want)
+			}
+			list := NewASList(got)
+			for ip, want := range map[string]ASN{"1.0.0.1": 13335, "1000::1": 0} {
+				if as, found := list.Find(netip.MustParseAddr(ip)); !found || as.ASNumber != want {
+					t.Errorf("Find(%s) = %v, %t, want %s", ip, as, found, want)
+				}
+			}
+			if as, found := list.Find(netip.MustParseAddr("2.0.0.1")); found {
+				t.Errorf("Find(2.0.0.1) = %v, want not found", as)
+			}
+		})
+	}
+}
+
+func TestWriteMMDB_Aliases(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    MMDBOptions
+		aliased bool
+	}{
+		{"default", MMDBOptions{}, true},
+		{"disabled", MMDBOptions{DisableIPv4Aliases: true}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := WriteMMDB(&buf, testMMDBZones(), tt.opts); err != nil {
+				t.Fatal(err)
+			}
+			db, err := parseMMDB(buf.Bytes())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			want, found := mmdbLookup(db, netip.MustParseAddr("1.0.0.1"))
+			if !found {
+				t.Fatal("mmdbLookup(1.0.0.1) not found")
+			}
+			for _, ip := range []string{"::ffff:1.0.0.1", "2002:100:1::"} {
+				got, found := mmdbLookup(db, netip.MustParseAddr(ip))
+				if found != tt.aliased || found && got != want {
+					t.Errorf("mmdbLookup(%s) = %d, %t, want %d, %t", ip, got, found, want, tt.aliased)
+				}
+			}
+			if _, found := mmdbLookup(db, netip.MustParseAddr("2001:200::1")); !found {
+				t.Errorf("mmdbLookup(2001:200::1) not found")
+			}
+		})
+	}
+}
+
+func TestWriteMMDB_Metadata(t *testing.T) {
+	var buf bytes.Buffer
+	built := time.Unix(1700000000, 0)
+	opts := MMDBOptions{IPVersion: 4, Description: "asndb export", BuildTime: built}
+	if err := WriteMMDB(&buf, testMMDBZones()[:4], opts); err != nil {
+		t.Fatal(err)
+	}
+	b := buf.Bytes()
+	meta, _, err := mmdbDecoder(b[bytes.LastIndex(b, mmdbMetadataMarker)+len(mmdbMetadataMarker):]).decode(0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := meta.(map[string]interface{})
+	for key, want := range map[string]interface{}{
+		"record_size":                 uint16(24),
+		"ip_version":                  uint16(4),
+		"database_type":               "GeoLite2-ASN",
+		"languages":                   []interface{}{"en"},
+		"binary_format_major_version": uint16(2),
+		"binary_format_minor_version": uint16(0),
+		"build_epoch":                 uint64(1700000000),
+		"description":                 map[string]interface{}{"en": "asndb export"},
+	} {
+		if !reflect.DeepEqual(m[key], want) {
+			t.Errorf("metadata %s = %#v, want %#v", key, m[key], want)
+		}
+	}
+}
+
+func TestWriteMMDB_Invalid(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteMMDB(&buf, testMMDBZones(), MMDBOptions{IPVersion: 4}); err == nil {
+		t.Error("WriteMMDB() with IPv6 zones in IPv4 database error = nil")
+	}
+	if err := WriteMMDB(&buf, nil, MMDBOptions{IPVersion: 5}); err == nil {
+		t.Error("WriteMMDB() with ip version 5 error = nil")
+	}
+}
+
+func TestASList_WriteMMDB(t *testing.T) {
+	//enough zones to build a large tree, sharing their strings through pointers
+	var zones []AS
+	for i := 0; i < 20000; i++ {
+		zones = append(zones, AS{
+			StartIP:       addr4(uint32(i) << 8),
+			EndIP:         addr4(uint32(i)<<8 | 0x7f),
+			ASNumber:      ASN(i),
+			CountryCode:   []string{"US", "DE", "JP"}[i%3],
+			ASDescription: strings.Repeat("AS-", i%40) + "NET",
+		})
+	}
+	var buf bytes.Buffer
+	if err := NewASList(zones).WriteMMDB(&buf, MMDBOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	got, err := LoadFromMMDB(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, zones) {
+		t.Errorf("LoadFromMMDB() returned %d zones, want %d equal zones", len(got), len(zones))
+	}
+}
+
+func TestEncodeMMDBTree(t *testing.T) {
+	tests := []struct {
+		recordSize  int
+		left, right int
+		want        []byte
+	}{
+		{24, 0x123456, 0x789abc, []byte{0x12, 0x34, 0x56, 0x78, 0x9a, 0xbc}},
+		{28, 0xa123456, 0xb789abc, []byte{0x12, 0x34, 0x56, 0xab, 0x78, 0x9a, 0xbc}},
+		{32, 0x12345678, 0x9abcdef0, []byte{0x12, 0x34, 0x56, 0x78, 0x9a, 0xbc, 0xde, 0xf0}},
+	}
+	for _, tt := range tests {
+		if got := encodeMMDBTree([][2]int{{tt.left, tt.right}}, tt.recordSize); !bytes.Equal(got, tt.want) {
+			t.Errorf("%d: encodeMMDBTree() = %x, want %x", tt.recordSize, got, tt.want)
+		}
+	}
+
+	//empty, node and data records match the independently encoded fixture trees
+	nodes := [][2]int{{1, mmdbEmpty}, {mmdbData(0), 2}, {mmdbData(1 << 23), mmdbData(1<<27 - 100)}}
+	for _, recordSize := range []int{24, 28, 32} {
+		n := nodes
+		if recordSize == 24 {
+			n = nodes[:2]
+		}
+		if got, want := encodeMMDBTree(n, recordSize), mmdbFixtureTree(n, recordSize); !bytes.Equal(got, want) {
+			t.Errorf("%d: encodeMMDBTree() = %x, want %x", recordSize, got, want)
+		}
+	}
+	nodes = [][2]int{{mmdbData(1<<31 - 100), mmdbEmpty}}
+	if got, want := encodeMMDBTree(nodes, 32), mmdbFixtureTree(nodes, 32); !bytes.Equal(got, want) {
+		t.Errorf("32: encodeMMDBTree() = %x, want %x", got, want)
+	}
+}
+
+func TestMMDBEncoder(t *testing.T) {
+	for _, typ := range []int{mmdbString, mmdbMap, mmdbUint32, mmdbArray, mmdbUint64} {
+		for _, size := range []int{0, 28, 29, 284, 285, 65820, 65821, 1 << 20} {
+			e := newMMDBEncoder()
+			e.control(typ, size)
+			if want := mmdbControl(nil, typ, size); !bytes.Equal(e.b, want) {
+				t.Errorf("control(%d, %d) = %x, want %x", typ, size, e.b, want)
+			}
+		}
+	}
+
+	for _, offset := range []int{0, 2047, 2048, 526335, 1 << 30} {
+		e := newMMDBEncoder()
+		e.pointer(offset)
+		if want := mmdbEncode(nil, mmdbPointerTo(offset)); !bytes.Equal(e.b, want) {
+			t.Errorf("pointer(%d) = %x, want %x", offset, e.b, want)
+		}
+	}
+	//the fixtures never use the 3 byte pointer form
+	e := newMMDBEncoder()
+	e.pointer(526336 + 0x10203)
+	if want := []byte{mmdbPointer<<5 | 2<<3, 0x01, 0x02, 0x03}; !bytes.Equal(e.b, want) {
+		t.Errorf("pointer(%d) = %x, want %x", 526336+0x10203, e.b, want)
+	}
+
+	for _, n := range []uint64{0, 1, 255, 256, 4200000000, 1<<64 - 1} {
+		e := newMMDBEncoder()
+		e.uint(mmdbUint64, n)
+		if want := mmdbEncode(nil, n); !bytes.Equal(e.b, want) {
+			t.Errorf("uint(%d) = %x, want %x", n, e.b, want)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package asndb
+
+import (
+	"fmt"
+	"io"
+	"net/netip"
+	"strconv"
+	"strings"
+)
+
+// LoadFromPfx2as parses CAIDA RouteViews prefix2as data, which lists a prefix, prefix length and origin column per row.
+// The files are distributed gzip compressed, wrap reader in a gzip.Reader to load them directly.
+//
+// Prefixes announced by multiple origins, listed as "13335_209242", and AS-sets, listed as "1,2",
+// become one zone per distinct origin ASN, so ASList.FindAll reports every origin of such an address.
+// The data holds no country code or description, they are left empty.
+// Rejected rows are handled according to opts, see LoadFromTSVWithOptions.
+func LoadFromPfx2as(reader io.Reader, opts LoadOptions) ([]AS, error) {
+	var s []AS
+	err := scanRows(reader, opts, func(line string) error {
+		zones, err := parsePfx2asRow(line)
+		if err != nil {
+			return err
+		}
+		s = append(s, zones...)
+		return nil
+	})
+	return s, err
+}
+
+func parsePfx2asRow(line string) ([]AS, error) {
+	parts := strings.Split(line, "\t")
+	if len(parts) < 3 {
+		return nil, fmt.Errorf(`%w: want 3 parts got %d`, ErrMalformedRow, len(parts))
+	}
+
+	addr, err := netip.ParseAddr(parts[0])
+	if err != nil || addr.Zone() != "" {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidAddress, parts[0])
+	}
+	bits, err := strconv.Atoi(parts[1])
+	if err != nil || bits < 0 || bits > addr.BitLen() {
+		return nil, fmt.Errorf("%w: prefix length %q", ErrMalformedRow, parts[1])
+	}
+	start, end := prefixRange(netip.PrefixFrom(addr, bits))
+
+	origins, err := parsePfx2asOrigins(parts[2])
+	if err != nil {
+		return nil, err
+	}
+	zones := make([]AS, len(origins))
+	for i, asn := range origins {
+		zones[i] = AS{StartIP: start, EndIP: end, ASNumber: asn}
+	}
+	return zones, nil
+}
+
+// parsePfx2asOrigins parses an origin column, returning every distinct ASN in order of appearance.
+func parsePfx2asOrigins(s string) ([]ASN, error) {
+	var origins []ASN
+	for _, moas := range strings.Split(s, "_") {
+		for _, text := range strings.Split(moas, ",") {
+			asn, err := ParseASN(text)
+			if err != nil {
+				return nil, err
+			}
+			if !containsASN(origins, asn) {
+				origins = append(origins, asn)
+			}
+		}
+	}
+	return origins, nil
+}
+
+func containsASN(s []ASN, asn ASN) bool {
+	for _, v := range s {
+		if v == asn {
+			return true
+		}
+	}
+	return false
+}
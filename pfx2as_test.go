@@ -0,0 +1,86 @@
+package asndb
+
+import (
+	"errors"
+	"net/netip"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testPfx2as = "1.0.0.0\t24\t13335\n" +
+	"1.0.4.0\t22\t38803\n" +
+	"1.1.1.0\t24\t13335_209242\n" +
+	"5.8.16.0\t21\t1,2\n" +
+	"8.8.8.0\t24\t15169_15169,36040\n" +
+	"2001:200::\t32\t2500\n" +
+	"2606:4700::\t32\t13335\n"
+
+func TestLoadFromPfx2as(t *testing.T) {
+	got, err := LoadFromPfx2as(strings.NewReader(testPfx2as), LoadOptions{})
+	if err != nil {
+		t.Fatalf("LoadFromPfx2as() error = %v", err)
+	}
+	zone := func(start, end string, asn ASN) AS {
+		return AS{StartIP: netip.MustParseAddr(start), EndIP: netip.MustParseAddr(end), ASNumber: asn}
+	}
+	want := []AS{
+		zone("1.0.0.0", "1.0.0.255", 13335),
+		zone("1.0.4.0", "1.0.7.255", 38803),
+		zone("1.1.1.0", "1.1.1.255", 13335),
+		zone("1.1.1.0", "1.1.1.255", 209242),
+		zone("5.8.16.0", "5.8.23.255", 1),
+		zone("5.8.16.0", "5.8.23.255", 2),
+		zone("8.8.8.0", "8.8.8.255", 15169),
+		zone("8.8.8.0", "8.8.8.255", 36040),
+		zone("2001:200::", "2001:200:ffff:ffff:ffff:ffff:ffff:ffff", 2500),
+		zone("2606:4700::", "2606:4700:ffff:ffff:ffff:ffff:ffff:ffff", 13335),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("LoadFromPfx2as() = %v, want %v", got, want)
+	}
+
+	//every origin of a multi-origin prefix is reported
+	list := NewASList(got)
+	var origins []ASN
+	for _, as := range list.FindAll(netip.MustParseAddr("1.1.1.1")) {
+		origins = append(origins, as.ASNumber)
+	}
+	if want := []ASN{209242, 13335}; !reflect.DeepEqual(origins, want) {
+		t.Errorf("ASList.FindAll(1.1.1.1) origins = %v, want %v", origins, want)
+	}
+}
+
+func TestLoadFromPfx2as_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		err  error
+	}{
+		{"parts", "1.0.0.0\t24", ErrMalformedRow},
+		{"address", "1.0.0\t24\t13335", ErrInvalidAddress},
+		{"zone", "fe80::%eth0\t64\t13335", ErrInvalidAddress},
+		{"length", "1.0.0.0\t33\t13335", ErrMalformedRow},
+		{"negative length", "1.0.0.0\t-1\t13335", ErrMalformedRow},
+		{"origin", "1.0.0.0\t24\tx", ErrInvalidASN},
+		{"empty origin", "1.0.0.0\t24\t", ErrInvalidASN},
+		{"empty moas origin", "1.0.0.0\t24\t13335_", ErrInvalidASN},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadFromPfx2as(strings.NewReader(tt.line), LoadOptions{})
+			if !errors.Is(err, tt.err) {
+				t.Errorf("LoadFromPfx2as() error = %v, want %v", err, tt.err)
+			}
+		})
+	}
+
+	s, err := LoadFromPfx2as(strings.NewReader("bad\n"+testPfx2as), LoadOptions{Mode: LoadLenient})
+	var loadErr *LoadError
+	if !errors.As(err, &loadErr) || len(loadErr.Rows) != 1 || loadErr.Rows[0].Line != 1 {
+		t.Errorf("LoadFromPfx2as() lenient error = %v, want 1 rejected row", err)
+	}
+	if len(s) != 10 {
+		t.Errorf("LoadFromPfx2as() lenient returned %d zones, want 10", len(s))
+	}
+}
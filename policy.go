@@ -0,0 +1,137 @@
+package asndb
+
+import (
+	"net/netip"
+	"sort"
+)
+
+// Policy decides which AS zone ASList.Find returns when multiple zones claim an IP.
+type Policy int
+
+const (
+	// PolicyClosest returns the zone with the closest StartIP, this is the default.
+	PolicyClosest Policy = iota
+	// PolicyMostSpecific returns the zone with the smallest range, like longest prefix match routing.
+	PolicyMostSpecific
+	// PolicyLargest returns the zone with the largest range.
+	PolicyLargest
+	// PolicyFirstLoaded returns the zone that came first in the slice given to NewASListWithOptions.
+	PolicyFirstLoaded
+	// PolicyPriority returns the zone with the highest ListOptions.Priority.
+	PolicyPriority
+)
+
+// ListOptions configures an ASList created by NewASListWithOptions.
+type ListOptions struct {
+	Policy Policy
+	// Priority returns the priority of the zone at index i of the given slice, used by PolicyPriority.
+	// When loading from multiple sources, the index can be used to tell the sources apart.
+	Priority func(i int, as AS) int
+	// PublicOnly drops zones belonging to non-public ASN, see ASN.IsPublic.
+	// Indexes given to Priority still refer to the unfiltered slice.
+	PublicOnly bool
+}
+
+// NewASListWithOptions creates a new registry from the given list of AS zones, see NewASList.
+// Ties between zones of equal rank under the policy are resolved by PolicyClosest.
+func NewASListWithOptions(s []AS, opts ListOptions) *ASList {
+	var rank func(i int) int
+	switch opts.Policy {
+	case PolicyFirstLoaded:
+		rank = func(i int) int {
+			return -i
+		}
+	case PolicyPriority:
+		rank = func(i int) int {
+			if opts.Priority == nil {
+				return 0
+			}
+			return opts.Priority(i, s[i])
+		}
+	}
+	return newRankedASList(s, opts.Policy, opts.PublicOnly, rank)
+}
+
+// newRankedASList creates a new registry from s using policy, rank returns the rank of the zone at index i of s.
+// rank is only called for the zones kept, and may be nil for policies without ranks.
+func newRankedASList(s []AS, policy Policy, publicOnly bool, rank func(i int) int) *ASList {
+	//sort an index of the zones, so the original position of every zone is known
+	order := make([]int, 0, len(s))
+	for i, as := range s {
+		if !publicOnly || as.IsPublic() {
+			order = append(order, i)
+		}
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return s[order[a]].StartIP.Less(s[order[b]].StartIP)
+	})
+	sorted := make([]AS, len(order))
+	for i, o := range order {
+		sorted[i] = s[o]
+	}
+
+	r := newSortedASList(sorted)
+	r.policy = policy
+	if rank != nil {
+		r.rank = make([]int, len(order))
+		for i, o := range order {
+			r.rank[i] = rank(o)
+		}
+	}
+	return r
+}
+
+// findByPolicy returns the zone ranked best by the policy out of every zone containing ip.
+func (r *ASList) findByPolicy(ip netip.Addr) (AS, bool) {
+	index := r.findAll(ip)
+	if len(index) == 0 {
+		return AS{}, false
+	}
+
+	//index is in descending order, so on ties the closest zone seen first is kept
+	best := index[0]
+	for _, i := range index[1:] {
+		if r.better(i, best) {
+			best = i
+		}
+	}
+	return r.at(best), true
+}
+
+// better reports if the zone at index i ranks above the zone at index j.
+func (r *ASList) better(i, j int) bool {
+	switch r.policy {
+	case PolicyMostSpecific:
+		return r.span(i).less(r.span(j))
+	case PolicyLargest:
+		return r.span(j).less(r.span(i))
+	case PolicyFirstLoaded, PolicyPriority:
+		return r.rank[i] > r.rank[j]
+	}
+	return false
+}
+
+// span returns the number of addresses in the zone at index i minus one.
+func (r *ASList) span(i int) uint128 {
+	j := i - len(r.head)
+	if _, odd := r.ends[i]; j >= 0 && !odd {
+		if j < len(r.v4Start) {
+			return uint128{lo: uint64(r.v4End[j] - r.v4Start[j])}
+		}
+		j -= len(r.v4Start)
+		return r.v6End[j].sub(r.v6Start[j])
+	}
+	//zones without a valid StartIP or with mixed families are compared by their 16 byte form
+	as := r.at(i)
+	return key6(as.EndIP).sub(key6(as.StartIP))
+}
+
+// sub returns u-v, wrapping around when v is larger.
+func (u uint128) sub(v uint128) uint128 {
+	lo := u.lo - v.lo
+	hi := u.hi - v.hi
+	if u.lo < v.lo {
+		hi--
+	}
+	return uint128{hi: hi, lo: lo}
+}
@@ -0,0 +1,131 @@
+package asndb
+
+import (
+	"net/netip"
+	"testing"
+)
+
+func TestASList_Policy(t *testing.T) {
+	//loaded in this order, with the source of each zone as its description
+	asl := []AS{
+		{ASNumber: 1, StartIP: netip.MustParseAddr("10.0.0.0"), EndIP: netip.MustParseAddr("10.255.255.255"), ASDescription: "b"},
+		{ASNumber: 2, StartIP: netip.MustParseAddr("10.1.0.0"), EndIP: netip.MustParseAddr("10.1.255.255"), ASDescription: "a"},
+		{ASNumber: 3, StartIP: netip.MustParseAddr("10.1.1.0"), EndIP: netip.MustParseAddr("10.1.1.255"), ASDescription: "b"},
+		{ASNumber: 4, StartIP: netip.MustParseAddr("10.1.1.128"), EndIP: netip.MustParseAddr("10.2.0.255"), ASDescription: "a"},
+		{ASNumber: 5, StartIP: netip.MustParseAddr("2001:db8::"), EndIP: netip.MustParseAddr("2001:db8::ffff"), ASDescription: "b"},
+		{ASNumber: 6, StartIP: netip.MustParseAddr("2001:db8::"), EndIP: netip.MustParseAddr("2001:db8::ff"), ASDescription: "b"},
+		{ASNumber: 7, StartIP: netip.MustParseAddr("::"), EndIP: netip.MustParseAddr("ffff:ffff:ffff:ffff:ffff:ffff:ffff:ffff"), ASDescription: "a"},
+	}
+	priority := func(i int, as AS) int {
+		if as.ASDescription == "a" {
+			return 1
+		}
+		return 0
+	}
+
+	tests := []struct {
+		ip   string
+		want map[Policy]int
+	}{
+		{
+			//nested: 1 > 2 > 3
+			ip: "10.1.1.1",
+			want: map[Policy]int{
+				PolicyClosest: 3, PolicyMostSpecific: 3, PolicyLargest: 1, PolicyFirstLoaded: 1, PolicyPriority: 2,
+			},
+		}, {
+			//partial overlap: 4 starts within 3, and ends outside of 2
+			ip: "10.1.1.200",
+			want: map[Policy]int{
+				PolicyClosest: 4, PolicyMostSpecific: 3, PolicyLargest: 1, PolicyFirstLoaded: 1, PolicyPriority: 4,
+			},
+		}, {
+			//only covered by 1 and 4
+			ip: "10.2.0.1",
+			want: map[Policy]int{
+				PolicyClosest: 4, PolicyMostSpecific: 4, PolicyLargest: 1, PolicyFirstLoaded: 1, PolicyPriority: 4,
+			},
+		}, {
+			//same StartIP, 6 is more specific
+			ip: "2001:db8::1",
+			want: map[Policy]int{
+				PolicyMostSpecific: 6, PolicyLargest: 7, PolicyFirstLoaded: 5, PolicyPriority: 7,
+			},
+		}, {
+			//the closest zone does not contain the ip, which hides 7 from PolicyClosest
+			ip: "2001:db8::1:0",
+			want: map[Policy]int{
+				PolicyClosest: -1, PolicyMostSpecific: 7, PolicyLargest: 7, PolicyFirstLoaded: 7, PolicyPriority: 7,
+			},
+		}, {
+			ip: "11.0.0.0",
+			want: map[Policy]int{
+				PolicyClosest: -1, PolicyMostSpecific: -1, PolicyLargest: -1, PolicyFirstLoaded: -1, PolicyPriority: -1,
+			},
+		},
+	}
+	for policy, name := range map[Policy]string{PolicyClosest: "Closest", PolicyMostSpecific: "MostSpecific",
+		PolicyLargest: "Largest", PolicyFirstLoaded: "FirstLoaded", PolicyPriority: "Priority"} {
+		t.Run(name, func(t *testing.T) {
+			r := NewASListWithOptions(asl, ListOptions{Policy: policy, Priority: priority})
+			for _, tt := range tests {
+				want, ok := tt.want[policy]
+				if !ok {
+					continue
+				}
+				ip := netip.MustParseAddr(tt.ip)
+				as, found := r.Find(ip)
+				if want == -1 {
+					if found {
+						t.Errorf("ASList.Find(%v) = %v, want not found", ip, as)
+					}
+					continue
+				}
+				if !found || as.ASNumber != ASN(want) {
+					t.Errorf("ASList.Find(%v) = %v, %v, want %v", ip, as.ASNumber, found, want)
+				}
+			}
+		})
+	}
+}
+
+func TestMergeASLists_Policy(t *testing.T) {
+	zone := func(asn ASN, start, end string) AS {
+		return AS{ASNumber: asn, StartIP: netip.MustParseAddr(start), EndIP: netip.MustParseAddr(end)}
+	}
+	//the wide zones are loaded after the narrow ones within each list
+	a := []AS{zone(1, "10.1.0.0", "10.1.0.255"), zone(2, "10.0.0.0", "10.255.255.255")}
+	b := []AS{zone(3, "10.1.0.128", "10.1.0.255"), zone(4, "10.0.0.0", "10.1.255.255")}
+	priority := func(i int, as AS) int {
+		if as.ASNumber == 1 {
+			return 5
+		}
+		return int(as.ASNumber)
+	}
+	ip := netip.MustParseAddr("10.1.0.200")
+
+	tests := []struct {
+		name string
+		got  *ASList
+		want ASN
+	}{
+		{"closest", MergeASLists(NewASList(a), NewASList(b)), 3},
+		{"most specific", MergeASLists(NewASListWithOptions(a, ListOptions{Policy: PolicyMostSpecific}), NewASList(b)), 3},
+		{"largest", MergeASLists(NewASListWithOptions(a, ListOptions{Policy: PolicyLargest}), NewASList(b)), 2},
+		{"first loaded", MergeASLists(NewASListWithOptions(b, ListOptions{Policy: PolicyFirstLoaded}),
+			NewASListWithOptions(a, ListOptions{Policy: PolicyFirstLoaded})), 3},
+		{"first loaded keeps load order", MergeASLists(NewASListWithOptions(a, ListOptions{Policy: PolicyFirstLoaded}), NewASList(b)), 1},
+		{"priority", MergeASLists(NewASListWithOptions(b, ListOptions{Policy: PolicyPriority, Priority: priority}),
+			NewASListWithOptions(a, ListOptions{Policy: PolicyPriority, Priority: priority})), 1},
+		{"priority of other policies", MergeASLists(NewASListWithOptions(b, ListOptions{Policy: PolicyPriority, Priority: priority}),
+			NewASList(a)), 4},
+		{"options", MergeASListsWithOptions(ListOptions{Policy: PolicyLargest}, NewASList(a), NewASList(b)), 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if as, found := tt.got.Find(ip); !found || as.ASNumber != tt.want {
+				t.Errorf("ASList.Find(%v) = %v, %v, want %v", ip, as.ASNumber, found, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package asndb
+
+import (
+	"math"
+	"net/netip"
+	"sort"
+)
+
+// Overlap describes how an AS zone relates to a queried range.
+type Overlap int
+
+const (
+	// OverlapPartial means the zone and the range only share part of their addresses.
+	OverlapPartial Overlap = iota
+	// OverlapCovers means the zone contains the whole range, including when both are equal.
+	OverlapCovers
+	// OverlapContained means the whole zone is within the range.
+	OverlapContained
+)
+
+func (o Overlap) String() string {
+	switch o {
+	case OverlapPartial:
+		return "partial"
+	case OverlapCovers:
+		return "covers"
+	case OverlapContained:
+		return "contained"
+	}
+	return "unknown"
+}
+
+// RangeMatch is an AS zone intersecting a queried range.
+type RangeMatch struct {
+	AS      AS
+	Overlap Overlap
+}
+
+// FindPrefix returns every AS zone intersecting the given prefix, see FindRange.
+func (r *ASList) FindPrefix(p netip.Prefix) []RangeMatch {
+	if !p.IsValid() {
+		return nil
+	}
+	start, end := prefixRange(p)
+	return r.FindRange(start, end)
+}
+
+// FindRange returns every AS zone intersecting start to end inclusive, ordered by StartIP.
+// Returns nil if start and end are of different families or start is above end.
+func (r *ASList) FindRange(start, end netip.Addr) []RangeMatch {
+	start, end = start.WithZone(""), end.WithZone("")
+	if !start.IsValid() || checkRange(start, end) != nil {
+		return nil
+	}
+
+	index := r.oddZones(start, end)
+	add := func(i int) {
+		if _, odd := r.ends[i]; !odd {
+			index = append(index, i)
+		}
+	}
+
+	base := len(r.head)
+	if start.Is4() {
+		r.v4Tree.overlap(r.v4Start, r.v4End, key4(start), key4(end), lessUint32, func(i int) {
+			add(base + i)
+		})
+	} else {
+		base += len(r.v4Start)
+		r.v6Tree.overlap(r.v6Start, r.v6End, key6(start), key6(end), lessUint128, func(i int) {
+			add(base + i)
+		})
+	}
+	if len(index) == 0 {
+		return nil
+	}
+	sort.Ints(index)
+
+	s := make([]RangeMatch, len(index))
+	for i, ix := range index {
+		as := r.at(ix)
+		m := RangeMatch{AS: as, Overlap: OverlapPartial}
+		switch {
+		case as.StartIP.Compare(start) <= 0 && as.EndIP.Compare(end) >= 0:
+			m.Overlap = OverlapCovers
+		case as.StartIP.Compare(start) >= 0 && as.EndIP.Compare(end) <= 0:
+			m.Overlap = OverlapContained
+		}
+		s[i] = m
+	}
+	return s
+}
+
+// prefixRange returns the first and last address of p.
+func prefixRange(p netip.Prefix) (netip.Addr, netip.Addr) {
+	p = p.Masked()
+	start := p.Addr()
+	if start.Is4() {
+		return start, addr4(key4(start) | math.MaxUint32>>p.Bits())
+	}
+	return start, key6(start).or(hostMask6(p.Bits())).addr()
+}
+
+// hostMask6 returns a mask of the host bits of an IPv6 prefix with the given length.
+func hostMask6(bits int) uint128 {
+	host := 128 - bits
+	if host >= 64 {
+		return uint128{hi: math.MaxUint64 >> (128 - host), lo: math.MaxUint64}
+	}
+	return uint128{lo: math.MaxUint64 >> (64 - host)}
+}
+
+func (u uint128) or(v uint128) uint128 {
+	return uint128{hi: u.hi | v.hi, lo: u.lo | v.lo}
+}
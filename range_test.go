@@ -0,0 +1,112 @@
+package asndb
+
+import (
+	"net/netip"
+	"reflect"
+	"testing"
+)
+
+func TestASList_FindPrefix(t *testing.T) {
+	r := NewASList([]AS{
+		{ASNumber: 1, StartIP: netip.MustParseAddr("203.0.0.0"), EndIP: netip.MustParseAddr("203.0.255.255")},
+		{ASNumber: 2, StartIP: netip.MustParseAddr("203.0.100.0"), EndIP: netip.MustParseAddr("203.0.112.255")},
+		{ASNumber: 3, StartIP: netip.MustParseAddr("203.0.112.0"), EndIP: netip.MustParseAddr("203.0.113.255")},
+		{ASNumber: 4, StartIP: netip.MustParseAddr("203.0.114.0"), EndIP: netip.MustParseAddr("203.0.114.0")},
+		{ASNumber: 5, StartIP: netip.MustParseAddr("203.0.115.0"), EndIP: netip.MustParseAddr("203.0.120.255")},
+		{ASNumber: 6, StartIP: netip.MustParseAddr("203.0.116.0"), EndIP: netip.MustParseAddr("203.0.120.255")},
+		{ASNumber: 7, StartIP: netip.MustParseAddr("2001:db8::"), EndIP: netip.MustParseAddr("2001:db8::ffff")},
+		{ASNumber: 8, StartIP: netip.MustParseAddr("2001:db8:1::"), EndIP: netip.MustParseAddr("2001:db8:1::ffff")},
+	})
+
+	type match struct {
+		asn     ASN
+		overlap Overlap
+	}
+	tests := []struct {
+		name   string
+		prefix netip.Prefix
+		want   []match
+	}{
+		{
+			name:   "v4",
+			prefix: netip.MustParsePrefix("203.0.113.0/22"),
+			want: []match{
+				{asn: 1, overlap: OverlapCovers},
+				{asn: 2, overlap: OverlapPartial},
+				{asn: 3, overlap: OverlapContained},
+				{asn: 4, overlap: OverlapContained},
+				{asn: 5, overlap: OverlapPartial},
+			},
+		}, {
+			name:   "equal",
+			prefix: netip.MustParsePrefix("203.0.114.0/32"),
+			want: []match{
+				{asn: 1, overlap: OverlapCovers},
+				{asn: 4, overlap: OverlapCovers},
+			},
+		}, {
+			name:   "unmasked",
+			prefix: netip.MustParsePrefix("2001:db8::1/32"),
+			want: []match{
+				{asn: 7, overlap: OverlapContained},
+				{asn: 8, overlap: OverlapContained},
+			},
+		}, {
+			name:   "v6",
+			prefix: netip.MustParsePrefix("2001:db8:1::/120"),
+			want: []match{
+				{asn: 8, overlap: OverlapCovers},
+			},
+		}, {
+			name:   "none",
+			prefix: netip.MustParsePrefix("198.51.100.0/24"),
+		}, {
+			name: "invalid",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []match
+			for _, m := range r.FindPrefix(tt.prefix) {
+				got = append(got, match{asn: m.AS.ASNumber, overlap: m.Overlap})
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ASList.FindPrefix(%v) = %v, want %v", tt.prefix, got, tt.want)
+			}
+		})
+	}
+
+	got := r.FindRange(netip.MustParseAddr("2001:db8::ff00"), netip.MustParseAddr("2001:db8:1::ff"))
+	if len(got) != 2 || got[0].Overlap != OverlapPartial || got[1].Overlap != OverlapPartial {
+		t.Errorf("ASList.FindRange() = %v, want 2 partial overlaps", got)
+	}
+	if got := r.FindRange(netip.MustParseAddr("203.0.114.1"), netip.MustParseAddr("203.0.0.0")); got != nil {
+		t.Errorf("ASList.FindRange() inverted = %v, want nil", got)
+	}
+	if got := r.FindRange(netip.MustParseAddr("203.0.114.1"), netip.MustParseAddr("2001:db8::")); got != nil {
+		t.Errorf("ASList.FindRange() mixed = %v, want nil", got)
+	}
+}
+
+func TestPrefixRange(t *testing.T) {
+	tests := []struct {
+		prefix    string
+		wantStart string
+		wantEnd   string
+	}{
+		{prefix: "0.0.0.0/0", wantStart: "0.0.0.0", wantEnd: "255.255.255.255"},
+		{prefix: "10.1.2.3/8", wantStart: "10.0.0.0", wantEnd: "10.255.255.255"},
+		{prefix: "10.1.2.3/32", wantStart: "10.1.2.3", wantEnd: "10.1.2.3"},
+		{prefix: "::/0", wantStart: "::", wantEnd: "ffff:ffff:ffff:ffff:ffff:ffff:ffff:ffff"},
+		{prefix: "2001:db8::/32", wantStart: "2001:db8::", wantEnd: "2001:db8:ffff:ffff:ffff:ffff:ffff:ffff"},
+		{prefix: "2001:db8::/64", wantStart: "2001:db8::", wantEnd: "2001:db8::ffff:ffff:ffff:ffff"},
+		{prefix: "2001:db8::/65", wantStart: "2001:db8::", wantEnd: "2001:db8::7fff:ffff:ffff:ffff"},
+		{prefix: "2001:db8::1/128", wantStart: "2001:db8::1", wantEnd: "2001:db8::1"},
+	}
+	for _, tt := range tests {
+		start, end := prefixRange(netip.MustParsePrefix(tt.prefix))
+		if start.String() != tt.wantStart || end.String() != tt.wantEnd {
+			t.Errorf("prefixRange(%v) = %v, %v, want %v, %v", tt.prefix, start, end, tt.wantStart, tt.wantEnd)
+		}
+	}
+}
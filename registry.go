@@ -0,0 +1,177 @@
+package asndb
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/netip"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Source provides AS zones to a Registry.
+type Source interface {
+	Load(ctx context.Context) ([]AS, error)
+}
+
+// SourceFunc adapts a function into a Source.
+type SourceFunc func(ctx context.Context) ([]AS, error)
+
+// Load calls f.
+func (f SourceFunc) Load(ctx context.Context) ([]AS, error) {
+	return f(ctx)
+}
+
+// DownloadSource is a Source that downloads and parses tsv data from URL.
+type DownloadSource struct {
+	// Downloader is used for downloading, the zero Downloader is used when nil.
+	Downloader *Downloader
+	URL        string
+	Options    LoadOptions
+}
+
+// Load downloads and parses the tsv data.
+func (s DownloadSource) Load(ctx context.Context) ([]AS, error) {
+	d := s.Downloader
+	if d == nil {
+		d = &Downloader{}
+	}
+	r, err := d.Download(ctx, s.URL)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return LoadFromTSVWithOptions(r, s.Options)
+}
+
+// ReaderSource returns a Source that parses tsv data from the reader returned by open.
+func ReaderSource(open func() (io.ReadCloser, error), opts LoadOptions) Source {
+	return SourceFunc(func(ctx context.Context) ([]AS, error) {
+		r, err := open()
+		if err != nil {
+			return nil, err
+		}
+		defer r.Close()
+		return LoadFromTSVWithOptions(r, opts)
+	})
+}
+
+// MMDBSource returns a Source that parses a MaxMind DB from the reader returned by open, see LoadFromMMDB.
+func MMDBSource(open func() (io.ReadCloser, error)) Source {
+	return SourceFunc(func(ctx context.Context) ([]AS, error) {
+		r, err := open()
+		if err != nil {
+			return nil, err
+		}
+		defer r.Close()
+		return LoadFromMMDB(r)
+	})
+}
+
+// Snapshot holds an ASList and ASNMap built from the same AS zones.
+// A Snapshot is never modified after creation.
+type Snapshot struct {
+	List *ASList
+	Map  *ASNMap
+	// Created is when the snapshot got built.
+	Created time.Time
+}
+
+// NewSnapshot builds a new Snapshot from the given list of AS zones.
+func NewSnapshot(s []AS) *Snapshot {
+	return &Snapshot{
+		List:    NewASList(s),
+		Map:     NewASNMap(s),
+		Created: time.Now(),
+	}
+}
+
+// Registry serves lookups from a Snapshot that can be replaced while in use.
+// Lookups never block, a lookup in progress during a swap finishes on the old snapshot,
+// which is released once no readers hold it anymore.
+type Registry struct {
+	current atomic.Value //*Snapshot
+
+	//mu serialises swaps and guards onReload
+	mu       sync.Mutex
+	onReload []func(old, new *Snapshot)
+
+	//loadMu serialises Reload and Updater.Update from loading until swapping,
+	//so an older load never replaces a newer one, and validated data is swapped in over the snapshot it got validated against
+	loadMu sync.Mutex
+}
+
+// NewRegistry creates a new Registry serving the given list of AS zones.
+func NewRegistry(s []AS) *Registry {
+	r := &Registry{}
+	r.current.Store(NewSnapshot(s))
+	return r
+}
+
+// Snapshot returns the current snapshot.
+// Use it to run multiple lookups against the same data.
+func (r *Registry) Snapshot() *Snapshot {
+	return r.current.Load().(*Snapshot)
+}
+
+// Find finds and returns the AS zone for a given IP address, see ASList.Find.
+func (r *Registry) Find(ip netip.Addr) (AS, bool) {
+	return r.Snapshot().List.Find(ip)
+}
+
+// Lookup finds the AS zone for a given IP address and classifies it, see ASList.Lookup.
+func (r *Registry) Lookup(ip netip.Addr) LookupResult {
+	return r.Snapshot().List.Lookup(ip)
+}
+
+// ListAS returns a list of AS zones controlled by given asn, see ASNMap.ListAS.
+func (r *Registry) ListAS(asn ASN) ([]AS, bool) {
+	return r.Snapshot().Map.ListAS(asn)
+}
+
+// ListASN returns a list of ASN, see ASNMap.ListASN.
+func (r *Registry) ListASN() []AS {
+	return r.Snapshot().Map.ListASN()
+}
+
+// OnReload registers fn to be called after every swap with the old and new snapshot.
+// Hooks are called in order of registration, and never concurrently.
+func (r *Registry) OnReload(fn func(old, new *Snapshot)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.onReload = append(r.onReload, fn)
+}
+
+// Swap builds a new snapshot from the given list of AS zones and atomically replaces the current one.
+// It returns the new snapshot.
+func (r *Registry) Swap(s []AS) *Snapshot {
+	return r.swap(NewSnapshot(s))
+}
+
+// Reload loads AS zones from src and swaps them in.
+// The current snapshot is kept when loading fails,
+// unless the error is a *LoadError from a lenient loader, in which case the parsed rows are still swapped in
+// as long as there is at least one of them.
+// Concurrent calls to Reload and Updater.Update are serialised.
+func (r *Registry) Reload(ctx context.Context, src Source) error {
+	r.loadMu.Lock()
+	defer r.loadMu.Unlock()
+	s, err := src.Load(ctx)
+	var loadErr *LoadError
+	if err != nil && (!errors.As(err, &loadErr) || len(s) == 0) {
+		return err
+	}
+	r.Swap(s)
+	return err
+}
+
+func (r *Registry) swap(snap *Snapshot) *Snapshot {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	old := r.current.Swap(snap).(*Snapshot)
+	for _, fn := range r.onReload {
+		fn(old, snap)
+	}
+	return snap
+}
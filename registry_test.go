@@ -0,0 +1,122 @@
+package asndb
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/netip"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestRegistry_Reload(t *testing.T) {
+	r := NewRegistry([]AS{
+		{StartIP: netip.MustParseAddr("1.0.0.0"), EndIP: netip.MustParseAddr("1.0.0.255"), ASNumber: 1},
+	})
+	ip := netip.MustParseAddr("1.0.0.1")
+	if as, found := r.Find(ip); !found || as.ASNumber != 1 {
+		t.Fatalf("Registry.Find() = %v, %v, want 1, true", as.ASNumber, found)
+	}
+
+	var hookOld, hookNew *Snapshot
+	r.OnReload(func(old, new *Snapshot) {
+		hookOld, hookNew = old, new
+	})
+
+	t.Run("Swap", func(t *testing.T) {
+		before := r.Snapshot()
+		err := r.Reload(context.Background(), ReaderSource(func() (io.ReadCloser, error) {
+			return io.NopCloser(strings.NewReader(testTSV)), nil
+		}, LoadOptions{}))
+		if err != nil {
+			t.Fatalf("Registry.Reload() error = %v", err)
+		}
+		if hookOld != before || hookNew != r.Snapshot() {
+			t.Errorf("OnReload() got old %p new %p, want %p %p", hookOld, hookNew, before, r.Snapshot())
+		}
+		if as, found := r.Find(ip); !found || as.ASNumber != 13335 {
+			t.Errorf("Registry.Find() = %v, %v, want 13335, true", as.ASNumber, found)
+		}
+		if ls, found := r.ListAS(0); !found || len(ls) != 1 {
+			t.Errorf("Registry.ListAS(0) = %v, %v, want 1 zone", ls, found)
+		}
+		if ls := r.ListASN(); len(ls) != 2 {
+			t.Errorf("Registry.ListASN() = %v, want 2 ASN", ls)
+		}
+		//the old snapshot is left untouched for readers still holding it
+		if as, found := before.List.Find(ip); !found || as.ASNumber != 1 {
+			t.Errorf("old Snapshot.List.Find() = %v, %v, want 1, true", as.ASNumber, found)
+		}
+	})
+
+	t.Run("Failed", func(t *testing.T) {
+		before := r.Snapshot()
+		wantErr := errors.New("source failed")
+		err := r.Reload(context.Background(), SourceFunc(func(ctx context.Context) ([]AS, error) {
+			return nil, wantErr
+		}))
+		if err != wantErr {
+			t.Errorf("Registry.Reload() error = %v, want %v", err, wantErr)
+		}
+		if r.Snapshot() != before {
+			t.Errorf("Registry.Reload() swapped snapshot on failure")
+		}
+	})
+
+	t.Run("Lenient", func(t *testing.T) {
+		err := r.Reload(context.Background(), ReaderSource(func() (io.ReadCloser, error) {
+			return io.NopCloser(strings.NewReader(testTSV + "foo\n")), nil
+		}, LoadOptions{Mode: LoadLenient}))
+		var loadErr *LoadError
+		if !errors.As(err, &loadErr) {
+			t.Errorf("Registry.Reload() error = %v, want *LoadError", err)
+		}
+		if got := r.Snapshot().List.IndexLen(); got != 2 {
+			t.Errorf("Registry.Reload() swapped in %v zones, want 2", got)
+		}
+	})
+
+	t.Run("Lenient without rows", func(t *testing.T) {
+		before := r.Snapshot()
+		err := r.Reload(context.Background(), ReaderSource(func() (io.ReadCloser, error) {
+			return io.NopCloser(strings.NewReader("<html>\nnot found\n")), nil
+		}, LoadOptions{Mode: LoadLenient}))
+		var loadErr *LoadError
+		if !errors.As(err, &loadErr) || len(loadErr.Rows) != 2 {
+			t.Errorf("Registry.Reload() error = %v, want *LoadError with 2 rows", err)
+		}
+		if r.Snapshot() != before {
+			t.Errorf("Registry.Reload() swapped in %v zones, want the previous snapshot", r.Snapshot().List.IndexLen())
+		}
+	})
+}
+
+func TestRegistry_Concurrent(t *testing.T) {
+	zones := func(asn ASN) []AS {
+		return []AS{{StartIP: netip.MustParseAddr("1.0.0.0"), EndIP: netip.MustParseAddr("1.0.0.255"), ASNumber: asn}}
+	}
+	r := NewRegistry(zones(1))
+	ip := netip.MustParseAddr("1.0.0.1")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for n := 0; n < 1000; n++ {
+				snap := r.Snapshot()
+				as, found := snap.List.Find(ip)
+				ls, _ := snap.Map.ListAS(as.ASNumber)
+				if !found || len(ls) != 1 {
+					t.Errorf("Snapshot inconsistent: Find() = %v, %v, ListAS() = %v", as, found, ls)
+					return
+				}
+			}
+		}()
+	}
+	for n := ASN(2); n < 100; n++ {
+		r.Swap(zones(n))
+	}
+	wg.Wait()
+}
@@ -0,0 +1,252 @@
+package asndb
+
+import (
+	"net/netip"
+	"sort"
+)
+
+// RIR is a regional internet registry.
+type RIR int
+
+const (
+	// RIRUnknown means the resource is not allocated to any RIR, such as reserved or unallocated space.
+	RIRUnknown RIR = iota
+	RIRARIN
+	RIRRIPENCC
+	RIRAPNIC
+	RIRLACNIC
+	RIRAFRINIC
+)
+
+func (r RIR) String() string {
+	switch r {
+	case RIRARIN:
+		return "ARIN"
+	case RIRRIPENCC:
+		return "RIPE NCC"
+	case RIRAPNIC:
+		return "APNIC"
+	case RIRLACNIC:
+		return "LACNIC"
+	case RIRAFRINIC:
+		return "AFRINIC"
+	}
+	return "unknown"
+}
+
+// The tables below follow the IANA allocations to the RIRs:
+// the AS number registries, the IPv4 address space registry and the IPv6 global unicast address assignments.
+// They attribute resources to the RIR that IANA allocated them to, legacy resources later transferred to another RIR,
+// such as early ASN and /16 networks moved by the early registration transfer project, keep their original RIR.
+//
+// 32-bit ASN are allocated by IANA in blocks of 1024 out of one 65536 ASN range per RIR,
+// whole ranges are attributed here so new blocks are covered without updating the table.
+
+type rirASNRange struct {
+	first, last ASN
+	rir         RIR
+}
+
+// rirASN is sorted and does not overlap, ASN outside of it are not allocated to an RIR.
+var rirASN = []rirASNRange{
+	{1, 1876, RIRARIN},
+	{1877, 1901, RIRRIPENCC},
+	{1902, 2042, RIRARIN},
+	{2043, 2043, RIRRIPENCC},
+	{2044, 2046, RIRARIN},
+	{2047, 2047, RIRRIPENCC},
+	{2048, 2106, RIRARIN},
+	{2107, 2136, RIRRIPENCC},
+	{2137, 2584, RIRARIN},
+	{2585, 2614, RIRRIPENCC},
+	{2615, 2772, RIRARIN},
+	{2773, 2822, RIRRIPENCC},
+	{2823, 2829, RIRARIN},
+	{2830, 2879, RIRRIPENCC},
+	{2880, 3153, RIRARIN},
+	{3154, 3353, RIRRIPENCC},
+	{3354, 4607, RIRARIN},
+	{4608, 4865, RIRAPNIC},
+	{4866, 5376, RIRARIN},
+	{5377, 5631, RIRRIPENCC},
+	{5632, 6655, RIRARIN},
+	{6656, 6911, RIRRIPENCC},
+	{6912, 7466, RIRARIN},
+	{7467, 7722, RIRAPNIC},
+	{7723, 8191, RIRARIN},
+	{8192, 9215, RIRRIPENCC},
+	{9216, 10239, RIRAPNIC},
+	{10240, 12287, RIRARIN},
+	{12288, 13311, RIRRIPENCC},
+	{13312, 15359, RIRARIN},
+	{15360, 16383, RIRRIPENCC},
+	{16384, 17407, RIRARIN},
+	{17408, 18431, RIRAPNIC},
+	{18432, 20479, RIRARIN},
+	{20480, 21503, RIRRIPENCC},
+	{21504, 23455, RIRARIN},
+	{23457, 23551, RIRARIN},
+	{23552, 24575, RIRAPNIC},
+	{24576, 25599, RIRRIPENCC},
+	{25600, 27647, RIRARIN},
+	{27648, 28671, RIRLACNIC},
+	{28672, 29695, RIRRIPENCC},
+	{29696, 30719, RIRARIN},
+	{30720, 31743, RIRRIPENCC},
+	{31744, 33791, RIRARIN},
+	{33792, 35839, RIRRIPENCC},
+	{35840, 36863, RIRARIN},
+	{36864, 37887, RIRAFRINIC},
+	{37888, 38911, RIRAPNIC},
+	{38912, 39935, RIRRIPENCC},
+	{39936, 40959, RIRARIN},
+	{40960, 45055, RIRRIPENCC},
+	{45056, 46079, RIRAPNIC},
+	{46080, 47103, RIRARIN},
+	{47104, 52223, RIRRIPENCC},
+	{52224, 53247, RIRLACNIC},
+	{53248, 55295, RIRARIN},
+	{55296, 56319, RIRAPNIC},
+	{56320, 58367, RIRRIPENCC},
+	{58368, 59391, RIRAPNIC},
+	{59392, 61439, RIRRIPENCC},
+	{61440, 61951, RIRLACNIC},
+	{61952, 62463, RIRRIPENCC},
+	{62464, 63487, RIRARIN},
+	{63488, 63999, RIRAPNIC},
+	{64000, 64098, RIRARIN},
+	{64099, 64197, RIRRIPENCC},
+	{64198, 64296, RIRAFRINIC},
+	{64297, 64395, RIRARIN},
+	{131072, 196607, RIRAPNIC},
+	{196608, 262143, RIRRIPENCC},
+	{262144, 327679, RIRLACNIC},
+	{327680, 393215, RIRAFRINIC},
+	{393216, 458751, RIRARIN},
+}
+
+// rirIPv4 maps the first octet of an IPv4 address to its RIR.
+var rirIPv4 = func() (t [256]RIR) {
+	for _, r := range []struct {
+		first, last byte
+		rir         RIR
+	}{
+		{1, 1, RIRAPNIC}, {2, 2, RIRRIPENCC}, {3, 4, RIRARIN}, {5, 5, RIRRIPENCC}, {6, 9, RIRARIN},
+		{11, 13, RIRARIN}, {14, 14, RIRAPNIC}, {15, 24, RIRARIN}, {25, 25, RIRRIPENCC}, {26, 26, RIRARIN},
+		{27, 27, RIRAPNIC}, {28, 30, RIRARIN}, {31, 31, RIRRIPENCC}, {32, 35, RIRARIN}, {36, 36, RIRAPNIC},
+		{37, 37, RIRRIPENCC}, {38, 38, RIRARIN}, {39, 39, RIRAPNIC}, {40, 40, RIRARIN}, {41, 41, RIRAFRINIC},
+		{42, 43, RIRAPNIC}, {44, 45, RIRARIN}, {46, 46, RIRRIPENCC}, {47, 48, RIRARIN}, {49, 49, RIRAPNIC},
+		{50, 50, RIRARIN}, {51, 51, RIRRIPENCC}, {52, 52, RIRARIN}, {53, 53, RIRRIPENCC}, {54, 56, RIRARIN},
+		{57, 57, RIRRIPENCC}, {58, 61, RIRAPNIC}, {62, 62, RIRRIPENCC}, {63, 76, RIRARIN}, {77, 95, RIRRIPENCC},
+		{96, 100, RIRARIN}, {101, 101, RIRAPNIC}, {102, 102, RIRAFRINIC}, {103, 103, RIRAPNIC}, {104, 104, RIRARIN},
+		{105, 105, RIRAFRINIC}, {106, 106, RIRAPNIC}, {107, 108, RIRARIN}, {109, 109, RIRRIPENCC}, {110, 126, RIRAPNIC},
+		{128, 132, RIRARIN}, {133, 133, RIRAPNIC}, {134, 140, RIRARIN}, {141, 141, RIRRIPENCC}, {142, 144, RIRARIN},
+		{145, 145, RIRRIPENCC}, {146, 149, RIRARIN}, {150, 150, RIRAPNIC}, {151, 151, RIRRIPENCC}, {152, 152, RIRARIN},
+		{153, 153, RIRAPNIC}, {154, 154, RIRAFRINIC}, {155, 162, RIRARIN}, {163, 163, RIRAPNIC}, {164, 170, RIRARIN},
+		{171, 171, RIRAPNIC}, {172, 174, RIRARIN}, {175, 175, RIRAPNIC}, {176, 176, RIRRIPENCC}, {177, 177, RIRLACNIC},
+		{178, 178, RIRRIPENCC}, {179, 179, RIRLACNIC}, {180, 180, RIRAPNIC}, {181, 181, RIRLACNIC}, {182, 183, RIRAPNIC},
+		{184, 184, RIRARIN}, {185, 185, RIRRIPENCC}, {186, 187, RIRLACNIC}, {188, 188, RIRRIPENCC}, {189, 191, RIRLACNIC},
+		{192, 192, RIRARIN}, {193, 195, RIRRIPENCC}, {196, 197, RIRAFRINIC}, {198, 199, RIRARIN}, {200, 201, RIRLACNIC},
+		{202, 203, RIRAPNIC}, {204, 209, RIRARIN}, {210, 211, RIRAPNIC}, {212, 213, RIRRIPENCC}, {214, 216, RIRARIN},
+		{217, 217, RIRRIPENCC}, {218, 223, RIRAPNIC},
+	} {
+		for i := int(r.first); i <= int(r.last); i++ {
+			t[i] = r.rir
+		}
+	}
+	return t
+}()
+
+type rirPrefix struct {
+	prefix netip.Prefix
+	rir    RIR
+}
+
+// rirIPv6 does not overlap, addresses outside of it are not allocated to an RIR.
+var rirIPv6 = []rirPrefix{
+	{netip.MustParsePrefix("2001:200::/23"), RIRAPNIC},
+	{netip.MustParsePrefix("2001:400::/23"), RIRARIN},
+	{netip.MustParsePrefix("2001:600::/23"), RIRRIPENCC},
+	{netip.MustParsePrefix("2001:800::/22"), RIRRIPENCC},
+	{netip.MustParsePrefix("2001:c00::/23"), RIRAPNIC},
+	{netip.MustParsePrefix("2001:e00::/23"), RIRAPNIC},
+	{netip.MustParsePrefix("2001:1200::/23"), RIRLACNIC},
+	{netip.MustParsePrefix("2001:1400::/22"), RIRRIPENCC},
+	{netip.MustParsePrefix("2001:1800::/23"), RIRARIN},
+	{netip.MustParsePrefix("2001:1a00::/23"), RIRRIPENCC},
+	{netip.MustParsePrefix("2001:1c00::/22"), RIRRIPENCC},
+	{netip.MustParsePrefix("2001:2000::/20"), RIRRIPENCC},
+	{netip.MustParsePrefix("2001:3000::/21"), RIRRIPENCC},
+	{netip.MustParsePrefix("2001:3800::/22"), RIRRIPENCC},
+	{netip.MustParsePrefix("2001:4000::/23"), RIRRIPENCC},
+	{netip.MustParsePrefix("2001:4200::/23"), RIRAFRINIC},
+	{netip.MustParsePrefix("2001:4400::/23"), RIRAPNIC},
+	{netip.MustParsePrefix("2001:4600::/23"), RIRRIPENCC},
+	{netip.MustParsePrefix("2001:4800::/23"), RIRARIN},
+	{netip.MustParsePrefix("2001:4a00::/23"), RIRRIPENCC},
+	{netip.MustParsePrefix("2001:4c00::/23"), RIRRIPENCC},
+	{netip.MustParsePrefix("2001:5000::/20"), RIRRIPENCC},
+	{netip.MustParsePrefix("2001:8000::/19"), RIRAPNIC},
+	{netip.MustParsePrefix("2001:a000::/20"), RIRAPNIC},
+	{netip.MustParsePrefix("2001:b000::/20"), RIRAPNIC},
+	{netip.MustParsePrefix("2003::/18"), RIRRIPENCC},
+	{netip.MustParsePrefix("2400::/12"), RIRAPNIC},
+	{netip.MustParsePrefix("2600::/12"), RIRARIN},
+	{netip.MustParsePrefix("2610::/23"), RIRARIN},
+	{netip.MustParsePrefix("2620::/23"), RIRARIN},
+	{netip.MustParsePrefix("2630::/12"), RIRARIN},
+	{netip.MustParsePrefix("2800::/12"), RIRLACNIC},
+	{netip.MustParsePrefix("2a00::/12"), RIRRIPENCC},
+	{netip.MustParsePrefix("2a10::/12"), RIRRIPENCC},
+	{netip.MustParsePrefix("2c00::/12"), RIRAFRINIC},
+}
+
+// RIR returns the RIR responsible for the ASN.
+func (a ASN) RIR() RIR {
+	i := sort.Search(len(rirASN), func(i int) bool {
+		return rirASN[i].last >= a
+	})
+	if i < len(rirASN) && rirASN[i].first <= a {
+		return rirASN[i].rir
+	}
+	return RIRUnknown
+}
+
+// AddrRIR returns the RIR responsible for ip.
+// IPv4 addresses are attributed by their /8, IPv4-mapped IPv6 addresses are attributed like IPv4 addresses.
+// Special-purpose addresses, see LookupSpecialAddr, are never attributed to an RIR.
+func AddrRIR(ip netip.Addr) RIR {
+	ip = ip.WithZone("").Unmap()
+	if ClassifyAddr(ip) != AddrPublic {
+		return RIRUnknown
+	}
+	if ip.Is4() {
+		return rirIPv4[ip.As4()[0]]
+	}
+	for _, p := range rirIPv6 {
+		if p.prefix.Contains(ip) {
+			return p.rir
+		}
+	}
+	return RIRUnknown
+}
+
+// RIR returns the RIR responsible for the address space of the zone, by its StartIP.
+// Use AS.ASNumber.RIR() for the RIR responsible for its ASN.
+func (a AS) RIR() RIR {
+	return AddrRIR(a.StartIP)
+}
+
+// RIRASN returns the sorted list of ASN in the map the given RIR is responsible for.
+func (m *ASNMap) RIRASN(rir RIR) []ASN {
+	var s []ASN
+	for asn := range m.m {
+		if asn.RIR() == rir {
+			s = append(s, asn)
+		}
+	}
+	sort.Slice(s, func(i, j int) bool {
+		return s[i] < s[j]
+	})
+	return s
+}
@@ -0,0 +1,117 @@
+package asndb
+
+import (
+	"net/netip"
+	"reflect"
+	"testing"
+)
+
+func TestASN_RIR(t *testing.T) {
+	tests := []struct {
+		asn  ASN
+		want RIR
+	}{
+		{asn: 0, want: RIRUnknown},
+		{asn: 13335, want: RIRARIN},
+		{asn: 15169, want: RIRARIN},
+		{asn: 3320, want: RIRRIPENCC},
+		{asn: 24940, want: RIRRIPENCC},
+		{asn: 4608, want: RIRAPNIC},
+		{asn: 9304, want: RIRAPNIC},
+		{asn: 28573, want: RIRLACNIC},
+		{asn: 37100, want: RIRAFRINIC},
+		{asn: 23456, want: RIRUnknown},
+		{asn: 64512, want: RIRUnknown},
+		{asn: 131072, want: RIRAPNIC},
+		{asn: 199524, want: RIRRIPENCC},
+		{asn: 262287, want: RIRLACNIC},
+		{asn: 328608, want: RIRAFRINIC},
+		{asn: 396982, want: RIRARIN},
+		{asn: 4200000000, want: RIRUnknown},
+	}
+	for _, tt := range tests {
+		if got := tt.asn.RIR(); got != tt.want {
+			t.Errorf("%v.RIR() = %v, want %v", tt.asn, got, tt.want)
+		}
+	}
+
+	for i, r := range rirASN {
+		if r.first > r.last || i > 0 && rirASN[i-1].last >= r.first {
+			t.Errorf("rirASN[%d] = %v is not sorted or overlaps", i, r)
+		}
+		if !r.first.IsPublic() || !r.last.IsPublic() {
+			t.Errorf("rirASN[%d] = %v covers special-purpose ASN", i, r)
+		}
+	}
+}
+
+func TestAddrRIR(t *testing.T) {
+	tests := []struct {
+		ip   string
+		want RIR
+	}{
+		{ip: "1.1.1.1", want: RIRAPNIC},
+		{ip: "8.8.8.8", want: RIRARIN},
+		{ip: "2.2.2.2", want: RIRRIPENCC},
+		{ip: "41.0.0.1", want: RIRAFRINIC},
+		{ip: "200.1.1.1", want: RIRLACNIC},
+		{ip: "10.0.0.1", want: RIRUnknown},
+		{ip: "127.0.0.1", want: RIRUnknown},
+		{ip: "224.0.0.1", want: RIRUnknown},
+		{ip: "::ffff:8.8.8.8", want: RIRARIN},
+		{ip: "2001:4860:4860::8888", want: RIRARIN},
+		{ip: "2606:4700::1111", want: RIRARIN},
+		{ip: "2a01:4f8::1", want: RIRRIPENCC},
+		{ip: "2400:cb00::1", want: RIRAPNIC},
+		{ip: "2800:3f0::1", want: RIRLACNIC},
+		{ip: "2c0f:fb50::1", want: RIRAFRINIC},
+		{ip: "2001:db8::1", want: RIRUnknown},
+		{ip: "2001:3bff::1", want: RIRRIPENCC},
+		//reserved by IANA, between RIPE NCC allocations
+		{ip: "2001:3c00::1", want: RIRUnknown},
+		{ip: "fe80::1%eth0", want: RIRUnknown},
+	}
+	for _, tt := range tests {
+		if got := AddrRIR(netip.MustParseAddr(tt.ip)); got != tt.want {
+			t.Errorf("AddrRIR(%v) = %v, want %v", tt.ip, got, tt.want)
+		}
+	}
+	if got := AddrRIR(netip.Addr{}); got != RIRUnknown {
+		t.Errorf("AddrRIR(invalid) = %v, want %v", got, RIRUnknown)
+	}
+
+	for i, a := range rirIPv6 {
+		for _, b := range rirIPv6[i+1:] {
+			if a.prefix.Overlaps(b.prefix) {
+				t.Errorf("rirIPv6 %v overlaps %v", a.prefix, b.prefix)
+			}
+		}
+	}
+}
+
+func TestASNMap_RIRASN(t *testing.T) {
+	zones := []AS{
+		{StartIP: netip.MustParseAddr("1.1.1.0"), EndIP: netip.MustParseAddr("1.1.1.255"), ASNumber: 13335},
+		{StartIP: netip.MustParseAddr("5.9.0.0"), EndIP: netip.MustParseAddr("5.9.255.255"), ASNumber: 24940},
+		{StartIP: netip.MustParseAddr("8.8.8.0"), EndIP: netip.MustParseAddr("8.8.8.255"), ASNumber: 15169},
+		{ASNumber: 0},
+	}
+	m := NewASNMap(zones)
+	if got, want := m.RIRASN(RIRARIN), []ASN{13335, 15169}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ASNMap.RIRASN(ARIN) = %v, want %v", got, want)
+	}
+	if got, want := m.RIRASN(RIRUnknown), []ASN{0}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ASNMap.RIRASN(unknown) = %v, want %v", got, want)
+	}
+	if got := m.RIRASN(RIRLACNIC); got != nil {
+		t.Errorf("ASNMap.RIRASN(LACNIC) = %v, want nil", got)
+	}
+
+	//the zone and its ASN can be attributed to different RIR
+	if got := zones[0].RIR(); got != RIRAPNIC {
+		t.Errorf("AS.RIR() = %v, want %v", got, RIRAPNIC)
+	}
+	if got := zones[0].ASNumber.RIR(); got != RIRARIN {
+		t.Errorf("AS.ASNumber.RIR() = %v, want %v", got, RIRARIN)
+	}
+}
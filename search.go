@@ -0,0 +1,303 @@
+package asndb
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// SearchMode decides how ASNMap.Search matches a query against AS descriptions.
+type SearchMode int
+
+const (
+	// SearchSubstring matches descriptions containing the query, ignoring case.
+	SearchSubstring SearchMode = iota
+	// SearchTokens matches descriptions containing every word of the query, ignoring case and punctuation.
+	SearchTokens
+	// SearchFuzzy matches descriptions sharing enough trigrams with the query, tolerating typos.
+	SearchFuzzy
+)
+
+// DefaultMinSimilarity is the minimum trigram similarity used by SearchFuzzy when SearchOptions.MinSimilarity is unset.
+const DefaultMinSimilarity = 0.3
+
+// SearchOptions configures ASNMap.Search.
+type SearchOptions struct {
+	Mode SearchMode
+	// Offset and Limit select a page of the ranked results, a Limit of 0 returns all results after Offset.
+	Offset int
+	Limit  int
+	// MinSimilarity is the minimum trigram similarity between 0 and 1 required by SearchFuzzy.
+	MinSimilarity float64
+}
+
+// SearchResult is an ASN whose description matched a search.
+type SearchResult struct {
+	ASNumber      ASN
+	ASDescription string
+	// Score ranks the result between 0 and 1, higher is better.
+	Score float64
+}
+
+// Search searches the descriptions of all ASN for query.
+// Results are ranked by score then ASN, every ASN is listed once with its best matching description.
+// It returns the requested page of results, and the total number of results.
+func (m *ASNMap) Search(query string, opts SearchOptions) ([]SearchResult, int) {
+	m.searchOnce.Do(func() {
+		m.search = newSearchIndex(m.m)
+	})
+	idx := m.search
+
+	var scores map[int32]float64
+	switch opts.Mode {
+	case SearchSubstring:
+		scores = idx.substring(strings.ToLower(query))
+	case SearchTokens:
+		scores = idx.tokens(query)
+	case SearchFuzzy:
+		similarity := opts.MinSimilarity
+		if similarity <= 0 {
+			similarity = DefaultMinSimilarity
+		}
+		scores = idx.fuzzy(query, similarity)
+	}
+
+	//keep the best description of every ASN
+	best := make(map[ASN]SearchResult)
+	for d, score := range scores {
+		doc := idx.docs[d]
+		r, ok := best[doc.asn]
+		if !ok || score > r.Score || score == r.Score && doc.desc < r.ASDescription {
+			best[doc.asn] = SearchResult{ASNumber: doc.asn, ASDescription: doc.desc, Score: score}
+		}
+	}
+	results := make([]SearchResult, 0, len(best))
+	for _, r := range best {
+		results = append(results, r)
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].ASNumber < results[j].ASNumber
+	})
+
+	total := len(results)
+	if opts.Offset > 0 {
+		if opts.Offset >= total {
+			return nil, total
+		}
+		results = results[opts.Offset:]
+	}
+	if opts.Limit > 0 && opts.Limit < len(results) {
+		results = results[:opts.Limit]
+	}
+	return results, total
+}
+
+// searchIndex indexes every distinct description of every ASN.
+type searchIndex struct {
+	docs []searchDoc
+	//vocab holds every distinct word, words maps a word to its index in vocab
+	vocab []searchWord
+	words map[string]int32
+	//trigrams maps a trigram to the sorted list of words containing it
+	trigrams map[string][]int32
+}
+
+type searchDoc struct {
+	asn   ASN
+	desc  string
+	lower string
+	//words is the number of distinct words of the description
+	words int
+}
+
+type searchWord struct {
+	//docs is the sorted list of documents containing the word
+	docs     []int32
+	trigrams int
+}
+
+func newSearchIndex(m map[ASN][]AS) *searchIndex {
+	idx := &searchIndex{
+		words:    make(map[string]int32),
+		trigrams: make(map[string][]int32),
+	}
+	type key struct {
+		asn  ASN
+		desc string
+	}
+	seen := make(map[key]bool)
+	for asn, s := range m {
+		for _, as := range s {
+			k := key{asn: asn, desc: as.ASDescription}
+			if as.ASDescription == "" || seen[k] {
+				continue
+			}
+			seen[k] = true
+
+			d := int32(len(idx.docs))
+			words := tokenize(as.ASDescription)
+			for _, w := range words {
+				wi, ok := idx.words[w]
+				if !ok {
+					wi = int32(len(idx.vocab))
+					idx.words[w] = wi
+					trigrams := wordTrigrams(w)
+					for _, t := range trigrams {
+						idx.trigrams[t] = append(idx.trigrams[t], wi)
+					}
+					idx.vocab = append(idx.vocab, searchWord{trigrams: len(trigrams)})
+				}
+				idx.vocab[wi].docs = append(idx.vocab[wi].docs, d)
+			}
+			idx.docs = append(idx.docs, searchDoc{
+				asn:   asn,
+				desc:  as.ASDescription,
+				lower: strings.ToLower(as.ASDescription),
+				words: len(words),
+			})
+		}
+	}
+	return idx
+}
+
+// substring scores documents containing q, shorter descriptions and matches at the start score higher.
+func (idx *searchIndex) substring(q string) map[int32]float64 {
+	if q == "" {
+		return nil
+	}
+	scores := make(map[int32]float64)
+	for i, doc := range idx.docs {
+		at := strings.Index(doc.lower, q)
+		if at < 0 {
+			continue
+		}
+		score := float64(len(q)) / float64(len(doc.lower))
+		if at > 0 {
+			score /= 2
+		}
+		scores[int32(i)] = score
+	}
+	return scores
+}
+
+// tokens scores documents containing every word of q, by the share of their words matched.
+func (idx *searchIndex) tokens(q string) map[int32]float64 {
+	words := tokenize(q)
+	if len(words) == 0 {
+		return nil
+	}
+	lists := make([][]int32, len(words))
+	for i, w := range words {
+		wi, ok := idx.words[w]
+		if !ok {
+			return nil
+		}
+		lists[i] = idx.vocab[wi].docs
+	}
+	//intersect the shortest lists first
+	sort.Slice(lists, func(i, j int) bool {
+		return len(lists[i]) < len(lists[j])
+	})
+	docs := lists[0]
+	for _, l := range lists[1:] {
+		docs = intersect(docs, l)
+	}
+
+	scores := make(map[int32]float64, len(docs))
+	for _, d := range docs {
+		scores[d] = float64(len(words)) / float64(idx.docs[d].words)
+	}
+	return scores
+}
+
+// fuzzy scores documents by how similar their words are to the words of q.
+// Every word of q is matched to the most similar word of the document by the jaccard similarity of their trigrams,
+// and the score is the average similarity over all words of q.
+func (idx *searchIndex) fuzzy(q string, similarity float64) map[int32]float64 {
+	words := tokenize(q)
+	if len(words) == 0 {
+		return nil
+	}
+
+	sums := make(map[int32]float64)
+	for _, w := range words {
+		trigrams := wordTrigrams(w)
+		shared := make(map[int32]int)
+		for _, t := range trigrams {
+			for _, wi := range idx.trigrams[t] {
+				shared[wi]++
+			}
+		}
+
+		best := make(map[int32]float64)
+		for wi, n := range shared {
+			sim := float64(n) / float64(len(trigrams)+idx.vocab[wi].trigrams-n)
+			for _, d := range idx.vocab[wi].docs {
+				if sim > best[d] {
+					best[d] = sim
+				}
+			}
+		}
+		for d, sim := range best {
+			sums[d] += sim
+		}
+	}
+
+	scores := make(map[int32]float64)
+	for d, sum := range sums {
+		if score := sum / float64(len(words)); score >= similarity {
+			scores[d] = score
+		}
+	}
+	return scores
+}
+
+// tokenize splits s into distinct lower case words of letters and digits.
+func tokenize(s string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	return distinct(fields)
+}
+
+// wordTrigrams returns the distinct trigrams of a word, padded so short words and word starts carry weight.
+func wordTrigrams(w string) []string {
+	r := []rune("  " + w + " ")
+	trigrams := make([]string, 0, len(r)-2)
+	for i := 0; i+3 <= len(r); i++ {
+		trigrams = append(trigrams, string(r[i:i+3]))
+	}
+	return distinct(trigrams)
+}
+
+func distinct(s []string) []string {
+	seen := make(map[string]bool, len(s))
+	out := s[:0]
+	for _, v := range s {
+		if !seen[v] {
+			seen[v] = true
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// intersect returns the values found in both sorted lists.
+func intersect(a, b []int32) []int32 {
+	var out []int32
+	for len(a) > 0 && len(b) > 0 {
+		switch {
+		case a[0] < b[0]:
+			a = a[1:]
+		case a[0] > b[0]:
+			b = b[1:]
+		default:
+			out = append(out, a[0])
+			a, b = a[1:], b[1:]
+		}
+	}
+	return out
+}
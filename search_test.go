@@ -0,0 +1,78 @@
+package asndb
+
+import (
+	"reflect"
+	"testing"
+)
+
+func searchZones() []AS {
+	return []AS{
+		{ASNumber: 24940, ASDescription: "HETZNER-AS, DE"},
+		{ASNumber: 24940, ASDescription: "HETZNER-AS, DE"},
+		{ASNumber: 213230, ASDescription: "HETZNER-CLOUD2-AS, DE"},
+		{ASNumber: 212317, ASDescription: "HETZNER-HEL3-AS, FI"},
+		{ASNumber: 13335, ASDescription: "CLOUDFLARENET, US"},
+		{ASNumber: 16509, ASDescription: "AMAZON-02, US"},
+		{ASNumber: 14618, ASDescription: "AMAZON-AES, US"},
+		{ASNumber: 8075, ASDescription: "MICROSOFT-CORP-MSN-AS-BLOCK, US"},
+		{ASNumber: 0, ASDescription: ""},
+	}
+}
+
+func resultASN(r []SearchResult) []ASN {
+	var s []ASN
+	for _, v := range r {
+		s = append(s, v.ASNumber)
+	}
+	return s
+}
+
+func TestASNMap_Search(t *testing.T) {
+	m := NewASNMap(searchZones())
+	tests := []struct {
+		name      string
+		query     string
+		opts      SearchOptions
+		want      []ASN
+		wantTotal int
+	}{
+		{name: "substring", query: "hetzner", want: []ASN{24940, 212317, 213230}, wantTotal: 3},
+		{name: "substring case", query: "Cloud", want: []ASN{13335, 213230}, wantTotal: 2},
+		{name: "substring none", query: "google"},
+		{name: "substring empty", query: ""},
+		{name: "tokens", query: "amazon", opts: SearchOptions{Mode: SearchTokens}, want: []ASN{14618, 16509}, wantTotal: 2},
+		{name: "tokens all", query: "Hetzner, FI", opts: SearchOptions{Mode: SearchTokens}, want: []ASN{212317}, wantTotal: 1},
+		{name: "tokens whole words", query: "hetz", opts: SearchOptions{Mode: SearchTokens}},
+		{name: "fuzzy typo", query: "hetzer", opts: SearchOptions{Mode: SearchFuzzy}, want: []ASN{24940, 212317, 213230}, wantTotal: 3},
+		{name: "fuzzy typo 2", query: "cloudflair", opts: SearchOptions{Mode: SearchFuzzy, MinSimilarity: 0.4}, want: []ASN{13335}, wantTotal: 1},
+		{name: "fuzzy strict", query: "hetzer", opts: SearchOptions{Mode: SearchFuzzy, MinSimilarity: 0.9}},
+		{name: "page", query: "hetzner", opts: SearchOptions{Offset: 1, Limit: 1}, want: []ASN{212317}, wantTotal: 3},
+		{name: "page end", query: "hetzner", opts: SearchOptions{Offset: 2, Limit: 5}, want: []ASN{213230}, wantTotal: 3},
+		{name: "page out of bounds", query: "hetzner", opts: SearchOptions{Offset: 3}, wantTotal: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, total := m.Search(tt.query, tt.opts)
+			if !reflect.DeepEqual(resultASN(got), tt.want) || total != tt.wantTotal {
+				t.Errorf("ASNMap.Search(%q) = %v, %d, want %v, %d", tt.query, got, total, tt.want, tt.wantTotal)
+			}
+			for i, r := range got {
+				if r.Score <= 0 || r.Score > 1 || i > 0 && r.Score > got[i-1].Score {
+					t.Errorf("ASNMap.Search(%q) has bad score order %v", tt.query, got)
+				}
+			}
+		})
+	}
+}
+
+func TestASNMap_SearchDescription(t *testing.T) {
+	m := NewASNMap([]AS{
+		{ASNumber: 1, ASDescription: "EXAMPLE-NETWORK-WITH-A-LONG-NAME, US"},
+		{ASNumber: 1, ASDescription: "EXAMPLE, US"},
+	})
+	got, total := m.Search("example", SearchOptions{})
+	want := []SearchResult{{ASNumber: 1, ASDescription: "EXAMPLE, US", Score: 7.0 / 11}}
+	if !reflect.DeepEqual(got, want) || total != 1 {
+		t.Errorf("ASNMap.Search() = %v, %d, want %v, 1", got, total, want)
+	}
+}
@@ -0,0 +1,311 @@
+package asndb
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"net/netip"
+	"time"
+)
+
+// The binary snapshot format is laid out as follows, all integers are little endian:
+//
+//	header  64 bytes: magic, version, record size, created (unix nano), zone count, string count, string bytes
+//	records zone count * 48 bytes: start [16], end [16], asn u32, country u32, description u32, families u32
+//	offsets (string count + 1) * u32, string i spans strings[offsets[i]:offsets[i+1]]
+//	strings string bytes
+//	trailer crc32 (castagnoli) of everything above
+//
+// Records are sorted the same way as ASList, addresses are stored in their 16 byte form,
+// and their family is stored in families so they can be unmapped again.
+// Country codes and descriptions are interned into the string table, records only reference them by index.
+const (
+	snapshotMagic      = "ASNDBSNP"
+	snapshotVersion    = 1
+	snapshotHeaderSize = 64
+	snapshotRecordSize = 48
+	snapshotTrailer    = 4
+)
+
+// address families as stored in a record
+const (
+	familyInvalid = 0
+	familyIPv4    = 4
+	familyIPv6    = 6
+)
+
+// ErrInvalidSnapshot is wrapped by all errors caused by malformed snapshot data.
+var ErrInvalidSnapshot = errors.New("invalid snapshot")
+
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+// WriteSnapshot writes snap in the binary snapshot format, which can be read back with ReadSnapshot.
+// Only the zones of snap.List are written, the map is rebuilt from them when reading,
+// so zones left out of the list, such as those dropped by ListOptions.PublicOnly, are missing from the map as well.
+func WriteSnapshot(w io.Writer, snap *Snapshot) error {
+	zones := snap.List.zones()
+
+	//intern all strings, as most zones share their country code and description with other zones
+	var table []string
+	index := make(map[string]uint32)
+	var stringBytes uint64
+	intern := func(s string) uint32 {
+		i, ok := index[s]
+		if !ok {
+			i = uint32(len(table))
+			index[s] = i
+			table = append(table, s)
+			stringBytes += uint64(len(s))
+		}
+		return i
+	}
+	for _, as := range zones {
+		intern(as.CountryCode)
+		intern(as.ASDescription)
+	}
+	if stringBytes > 1<<32-1 {
+		return fmt.Errorf("snapshot string table too large: %d bytes", stringBytes)
+	}
+
+	crc := crc32.New(castagnoli)
+	bw := bufio.NewWriter(io.MultiWriter(w, crc))
+
+	header := make([]byte, snapshotHeaderSize)
+	copy(header, snapshotMagic)
+	binary.LittleEndian.PutUint32(header[8:], snapshotVersion)
+	binary.LittleEndian.PutUint32(header[12:], snapshotRecordSize)
+	if !snap.Created.IsZero() {
+		binary.LittleEndian.PutUint64(header[16:], uint64(snap.Created.UnixNano()))
+	}
+	binary.LittleEndian.PutUint64(header[24:], uint64(len(zones)))
+	binary.LittleEndian.PutUint64(header[32:], uint64(len(table)))
+	binary.LittleEndian.PutUint64(header[40:], stringBytes)
+	bw.Write(header)
+
+	record := make([]byte, snapshotRecordSize)
+	for _, as := range zones {
+		start, startFamily := encodeAddr(as.StartIP)
+		end, endFamily := encodeAddr(as.EndIP)
+		copy(record[0:16], start[:])
+		copy(record[16:32], end[:])
+		binary.LittleEndian.PutUint32(record[32:], uint32(as.ASNumber))
+		binary.LittleEndian.PutUint32(record[36:], index[as.CountryCode])
+		binary.LittleEndian.PutUint32(record[40:], index[as.ASDescription])
+		binary.LittleEndian.PutUint32(record[44:], uint32(startFamily)|uint32(endFamily)<<8)
+		bw.Write(record)
+	}
+
+	var offset uint32
+	var b [4]byte
+	for _, s := range table {
+		binary.LittleEndian.PutUint32(b[:], offset)
+		bw.Write(b[:])
+		offset += uint32(len(s))
+	}
+	binary.LittleEndian.PutUint32(b[:], offset)
+	bw.Write(b[:])
+	for _, s := range table {
+		bw.WriteString(s)
+	}
+
+	if err := bw.Flush(); err != nil {
+		return err
+	}
+	binary.LittleEndian.PutUint32(b[:], crc.Sum32())
+	_, err := w.Write(b[:])
+	return err
+}
+
+// ReadSnapshot reads a snapshot written by WriteSnapshot.
+// The data is verified against its checksum, and the zones are loaded without sorting them again.
+// Snapshots do not store the Policy of the list, it's read back with PolicyClosest, see ReadSnapshotWithOptions.
+func ReadSnapshot(r io.Reader) (*Snapshot, error) {
+	return ReadSnapshotWithOptions(r, ListOptions{})
+}
+
+// ReadSnapshotWithOptions reads a snapshot like ReadSnapshot, creating its ASList with opts as NewASListWithOptions does.
+// Zones are stored sorted by StartIP, so that order is the load order seen by PolicyFirstLoaded
+// and the index given to ListOptions.Priority, not the order of the slice the snapshot was originally built from.
+// The map is rebuilt from every stored zone, ListOptions.PublicOnly only filters the list.
+// The stored zones are those of the written list, see WriteSnapshot.
+func ReadSnapshotWithOptions(r io.Reader, opts ListOptions) (*Snapshot, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	v, err := parseSnapshot(b)
+	if err != nil {
+		return nil, err
+	}
+	if err := v.verify(); err != nil {
+		return nil, err
+	}
+
+	//every distinct string is only allocated once
+	table := make([]string, v.stringCount)
+	for i := range table {
+		table[i] = string(v.stringBytes(uint32(i)))
+	}
+
+	zones := make([]AS, v.zoneCount)
+	for i := range zones {
+		zones[i] = v.zone(i, func(i uint32) string {
+			return table[i]
+		})
+	}
+
+	list := newSortedASList(zones)
+	if opts.Policy != PolicyClosest || opts.PublicOnly {
+		list = NewASListWithOptions(zones, opts)
+	}
+	return &Snapshot{
+		List:    list,
+		Map:     NewASNMap(zones),
+		Created: v.created,
+	}, nil
+}
+
+// snapshotView provides access to the sections of binary snapshot data.
+type snapshotView struct {
+	data        []byte
+	created     time.Time
+	zoneCount   int
+	stringCount int
+	records     []byte
+	offsets     []byte
+	strings     []byte
+}
+
+// parseSnapshot validates the header and section bounds of b, the checksum is validated by verify.
+func parseSnapshot(b []byte) (snapshotView, error) {
+	if len(b) < snapshotHeaderSize+snapshotTrailer || string(b[:8]) != snapshotMagic {
+		return snapshotView{}, fmt.Errorf("%w: bad magic", ErrInvalidSnapshot)
+	}
+	if version := binary.LittleEndian.Uint32(b[8:]); version != snapshotVersion {
+		return snapshotView{}, fmt.Errorf("%w: unsupported version %d", ErrInvalidSnapshot, version)
+	}
+	if size := binary.LittleEndian.Uint32(b[12:]); size != snapshotRecordSize {
+		return snapshotView{}, fmt.Errorf("%w: unsupported record size %d", ErrInvalidSnapshot, size)
+	}
+	zoneCount := binary.LittleEndian.Uint64(b[24:])
+	stringCount := binary.LittleEndian.Uint64(b[32:])
+	stringBytes := binary.LittleEndian.Uint64(b[40:])
+
+	//bound every count by the data length first, so the section sizes below can not overflow
+	n := uint64(len(b))
+	if zoneCount > n/snapshotRecordSize || stringCount >= n/4 || stringBytes > n {
+		return snapshotView{}, fmt.Errorf("%w: truncated", ErrInvalidSnapshot)
+	}
+	recordsEnd := snapshotHeaderSize + zoneCount*snapshotRecordSize
+	offsetsEnd := recordsEnd + (stringCount+1)*4
+	stringsEnd := offsetsEnd + stringBytes
+	if stringsEnd+snapshotTrailer != n {
+		return snapshotView{}, fmt.Errorf("%w: want %d bytes got %d", ErrInvalidSnapshot, stringsEnd+snapshotTrailer, n)
+	}
+
+	v := snapshotView{
+		data:        b,
+		zoneCount:   int(zoneCount),
+		stringCount: int(stringCount),
+		records:     b[snapshotHeaderSize:recordsEnd],
+		offsets:     b[recordsEnd:offsetsEnd],
+		strings:     b[offsetsEnd:stringsEnd],
+	}
+	if created := int64(binary.LittleEndian.Uint64(b[16:])); created != 0 {
+		v.created = time.Unix(0, created)
+	}
+	return v, v.validate()
+}
+
+// validate checks that all string and record references are in bounds, and records are sorted.
+func (v snapshotView) validate() error {
+	prev := uint32(0)
+	for i := 0; i <= v.stringCount; i++ {
+		off := binary.LittleEndian.Uint32(v.offsets[i*4:])
+		if off < prev || uint64(off) > uint64(len(v.strings)) {
+			return fmt.Errorf("%w: string offset %d out of bounds", ErrInvalidSnapshot, i)
+		}
+		prev = off
+	}
+	for i := 0; i < v.zoneCount; i++ {
+		rec := v.record(i)
+		country := binary.LittleEndian.Uint32(rec[36:])
+		desc := binary.LittleEndian.Uint32(rec[40:])
+		if uint64(country) >= uint64(v.stringCount) || uint64(desc) >= uint64(v.stringCount) {
+			return fmt.Errorf("%w: record %d references unknown string", ErrInvalidSnapshot, i)
+		}
+		if !validFamily(rec[44]) || !validFamily(rec[45]) {
+			return fmt.Errorf("%w: record %d has unknown address family", ErrInvalidSnapshot, i)
+		}
+		if i > 0 && v.startIP(i).Less(v.startIP(i-1)) {
+			return fmt.Errorf("%w: record %d is not sorted", ErrInvalidSnapshot, i)
+		}
+	}
+	return nil
+}
+
+// verify checks the data against the trailing checksum.
+func (v snapshotView) verify() error {
+	body := v.data[:len(v.data)-snapshotTrailer]
+	want := binary.LittleEndian.Uint32(v.data[len(body):])
+	if got := crc32.Checksum(body, castagnoli); got != want {
+		return fmt.Errorf("%w: checksum mismatch", ErrInvalidSnapshot)
+	}
+	return nil
+}
+
+func (v snapshotView) record(i int) []byte {
+	return v.records[i*snapshotRecordSize : (i+1)*snapshotRecordSize]
+}
+
+// zone decodes the record at i into an AS, using str to look up strings.
+func (v snapshotView) zone(i int, str func(i uint32) string) AS {
+	rec := v.record(i)
+	return AS{
+		StartIP:       decodeAddr(rec[0:16], rec[44]),
+		EndIP:         decodeAddr(rec[16:32], rec[45]),
+		ASNumber:      ASN(binary.LittleEndian.Uint32(rec[32:])),
+		CountryCode:   str(binary.LittleEndian.Uint32(rec[36:])),
+		ASDescription: str(binary.LittleEndian.Uint32(rec[40:])),
+	}
+}
+
+func (v snapshotView) startIP(i int) netip.Addr {
+	rec := v.record(i)
+	return decodeAddr(rec[0:16], rec[44])
+}
+
+func (v snapshotView) stringBytes(i uint32) []byte {
+	start := binary.LittleEndian.Uint32(v.offsets[i*4:])
+	end := binary.LittleEndian.Uint32(v.offsets[(i+1)*4:])
+	return v.strings[start:end]
+}
+
+func validFamily(f byte) bool {
+	return f == familyInvalid || f == familyIPv4 || f == familyIPv6
+}
+
+func encodeAddr(ip netip.Addr) ([16]byte, byte) {
+	switch {
+	case ip.Is4():
+		return ip.As16(), familyIPv4
+	case ip.Is6():
+		return ip.As16(), familyIPv6
+	}
+	return [16]byte{}, familyInvalid
+}
+
+func decodeAddr(b []byte, family byte) netip.Addr {
+	var a [16]byte
+	copy(a[:], b)
+	switch family {
+	case familyIPv4:
+		return netip.AddrFrom16(a).Unmap()
+	case familyIPv6:
+		return netip.AddrFrom16(a)
+	}
+	return netip.Addr{}
+}
@@ -0,0 +1,168 @@
+package asndb
+
+import (
+	"bytes"
+	"errors"
+	"net/netip"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func testSnapshotZones() []AS {
+	return []AS{
+		{
+			StartIP:       netip.MustParseAddr("2001:200::"),
+			EndIP:         netip.MustParseAddr("2001:200:5ff:ffff:ffff:ffff:ffff:ffff"),
+			ASNumber:      2500,
+			CountryCode:   "JP",
+			ASDescription: "WIDE-BB WIDE Project",
+		}, {
+			StartIP:       netip.MustParseAddr("1.0.0.0"),
+			EndIP:         netip.MustParseAddr("1.0.0.255"),
+			ASNumber:      13335,
+			CountryCode:   "US",
+			ASDescription: "CLOUDFLARENET",
+		}, {
+			StartIP:       netip.MustParseAddr("1.0.1.0"),
+			EndIP:         netip.MustParseAddr("1.0.3.255"),
+			ASNumber:      0,
+			CountryCode:   "None",
+			ASDescription: "Not routed",
+		}, {
+			StartIP:       netip.MustParseAddr("1.1.1.0"),
+			EndIP:         netip.MustParseAddr("1.1.1.255"),
+			ASNumber:      13335,
+			CountryCode:   "US",
+			ASDescription: "CLOUDFLARENET",
+		}, {
+			ASNumber:      4200000000,
+			ASDescription: "no addresses",
+		},
+	}
+}
+
+func TestSnapshot_RoundTrip(t *testing.T) {
+	snap := NewSnapshot(testSnapshotZones())
+	snap.Created = time.Unix(1700000000, 0)
+
+	var buf bytes.Buffer
+	if err := WriteSnapshot(&buf, snap); err != nil {
+		t.Fatalf("WriteSnapshot() error = %v", err)
+	}
+	got, err := ReadSnapshot(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("ReadSnapshot() error = %v", err)
+	}
+
+	if !reflect.DeepEqual(got.List.zones(), snap.List.zones()) {
+		t.Errorf("ReadSnapshot().List = %v, want %v", got.List.zones(), snap.List.zones())
+	}
+	if !reflect.DeepEqual(got.Map.ListASN(), snap.Map.ListASN()) {
+		t.Errorf("ReadSnapshot().Map.ListASN() = %v, want %v", got.Map.ListASN(), snap.Map.ListASN())
+	}
+	if !got.Created.Equal(snap.Created) {
+		t.Errorf("ReadSnapshot().Created = %v, want %v", got.Created, snap.Created)
+	}
+	if as, found := got.List.Find(netip.MustParseAddr("1.1.1.1")); !found || as.ASNumber != 13335 {
+		t.Errorf("ReadSnapshot().List.Find() = %v, %v, want 13335, true", as, found)
+	}
+
+	//duplicated strings are interned, leaving 8 distinct strings totalling 63 bytes
+	if wantSize := snapshotHeaderSize + 5*snapshotRecordSize + 9*4 + 63 + snapshotTrailer; buf.Len() != wantSize {
+		t.Errorf("WriteSnapshot() wrote %v bytes, want %v", buf.Len(), wantSize)
+	}
+}
+
+func TestReadSnapshotWithOptions(t *testing.T) {
+	zones := []AS{
+		{StartIP: netip.MustParseAddr("10.0.0.0"), EndIP: netip.MustParseAddr("10.255.255.255"), ASNumber: 1},
+		{StartIP: netip.MustParseAddr("10.0.0.0"), EndIP: netip.MustParseAddr("10.0.0.255"), ASNumber: 2},
+		{StartIP: netip.MustParseAddr("10.0.1.0"), EndIP: netip.MustParseAddr("10.0.1.255"), ASNumber: 0},
+	}
+	var buf bytes.Buffer
+	if err := WriteSnapshot(&buf, NewSnapshot(zones)); err != nil {
+		t.Fatalf("WriteSnapshot() error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		opts ListOptions
+		ip   string
+		want ASN
+	}{
+		{"closest", ListOptions{}, "10.0.0.1", 2},
+		{"largest", ListOptions{Policy: PolicyLargest}, "10.0.0.1", 1},
+		{"most specific", ListOptions{Policy: PolicyMostSpecific}, "10.0.1.1", 0},
+		{"public only", ListOptions{Policy: PolicyMostSpecific, PublicOnly: true}, "10.0.1.1", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ReadSnapshotWithOptions(bytes.NewReader(buf.Bytes()), tt.opts)
+			if err != nil {
+				t.Fatalf("ReadSnapshotWithOptions() error = %v", err)
+			}
+			if as, found := got.List.Find(netip.MustParseAddr(tt.ip)); !found || as.ASNumber != tt.want {
+				t.Errorf("ReadSnapshotWithOptions().List.Find(%s) = %v, %v, want %s", tt.ip, as, found, tt.want)
+			}
+			if _, found := got.Map.ListAS(0); !found {
+				t.Errorf("ReadSnapshotWithOptions().Map.ListAS(0) not found")
+			}
+		})
+	}
+}
+
+func TestReadSnapshot_Invalid(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteSnapshot(&buf, NewSnapshot(testSnapshotZones())); err != nil {
+		t.Fatalf("WriteSnapshot() error = %v", err)
+	}
+	valid := buf.Bytes()
+
+	tests := []struct {
+		name   string
+		modify func(b []byte) []byte
+	}{
+		{name: "empty", modify: func(b []byte) []byte { return nil }},
+		{name: "magic", modify: func(b []byte) []byte { b[0] = 'X'; return b }},
+		{name: "version", modify: func(b []byte) []byte { b[8] = 2; return b }},
+		{name: "truncated", modify: func(b []byte) []byte { return b[:len(b)-10] }},
+		{name: "zone count", modify: func(b []byte) []byte { b[31] = 0xff; return b }},
+		{name: "checksum", modify: func(b []byte) []byte { b[snapshotHeaderSize] ^= 0xff; return b }},
+		{name: "string index", modify: func(b []byte) []byte { b[snapshotHeaderSize+36] = 0xff; return b }},
+		{name: "family", modify: func(b []byte) []byte { b[snapshotHeaderSize+44] = 5; return b }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := tt.modify(append([]byte(nil), valid...))
+			_, err := ReadSnapshot(bytes.NewReader(b))
+			if !errors.Is(err, ErrInvalidSnapshot) {
+				t.Errorf("ReadSnapshot() error = %v, want %v", err, ErrInvalidSnapshot)
+			}
+		})
+	}
+}
+
+func BenchmarkReadSnapshot(b *testing.B) {
+	loadDB()
+	var buf bytes.Buffer
+	if err := WriteSnapshot(&buf, &Snapshot{List: list, Map: NewASNMap(list.zones())}); err != nil {
+		panic(err)
+	}
+	if err := os.WriteFile("./ip2asn-combined.snapshot", buf.Bytes(), 0o644); err != nil {
+		panic(err)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		f, err := os.Open("./ip2asn-combined.snapshot")
+		if err != nil {
+			panic(err)
+		}
+		_, err = ReadSnapshot(f)
+		f.Close()
+		if err != nil {
+			panic(err)
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package asndb
+
+import (
+	"math/big"
+	"sort"
+)
+
+// AddressCount is a number of addresses, counted separately per family.
+type AddressCount struct {
+	IPv4 uint64
+	// IPv6 is never nil for counts returned by this package.
+	IPv6 *big.Int
+}
+
+// IPv6Blocks returns how many IPv6 blocks of the given prefix length the IPv6 addresses amount to, rounded down.
+// IPv6Blocks(48) counts /48 blocks.
+func (c AddressCount) IPv6Blocks(bits int) *big.Int {
+	if c.IPv6 == nil {
+		return new(big.Int)
+	}
+	return new(big.Int).Rsh(c.IPv6, uint(128-bits))
+}
+
+// AddressCount returns the number of addresses in this AS zone.
+func (a AS) AddressCount() AddressCount {
+	var g rangeGroup
+	g.add(a)
+	return g.count()
+}
+
+// Stats holds the address space held per ASN and per country code.
+// Addresses claimed by overlapping zones of the same ASN or country are only counted once.
+type Stats struct {
+	ByASN     map[ASN]AddressCount
+	ByCountry map[string]AddressCount
+	// Total is the address space covered by any zone.
+	Total AddressCount
+}
+
+// Stats computes the address space statistics of all zones in the list.
+func (r *ASList) Stats() *Stats {
+	return newStats(r.zones())
+}
+
+// Stats computes the address space statistics of all zones in the map.
+func (m *ASNMap) Stats() *Stats {
+	var s []AS
+	for _, as := range m.m {
+		s = append(s, as...)
+	}
+	return newStats(s)
+}
+
+// count returns the number of addresses in the union of all ranges.
+func (g *rangeGroup) count() AddressCount {
+	c := AddressCount{IPv6: new(big.Int)}
+	for _, r := range mergeKeyRanges(g.v4) {
+		c.IPv4 += r.size4()
+	}
+	for _, r := range mergeKeyRanges(g.v6) {
+		c.IPv6.Add(c.IPv6, r.size6())
+	}
+	return c
+}
+
+func newStats(s []AS) *Stats {
+	byASN := make(map[ASN]*rangeGroup)
+	byCountry := make(map[string]*rangeGroup)
+	total := &rangeGroup{}
+	for _, as := range s {
+		g, ok := byASN[as.ASNumber]
+		if !ok {
+			g = &rangeGroup{}
+			byASN[as.ASNumber] = g
+		}
+		g.add(as)
+
+		g, ok = byCountry[as.CountryCode]
+		if !ok {
+			g = &rangeGroup{}
+			byCountry[as.CountryCode] = g
+		}
+		g.add(as)
+		total.add(as)
+	}
+
+	st := &Stats{
+		ByASN:     make(map[ASN]AddressCount, len(byASN)),
+		ByCountry: make(map[string]AddressCount, len(byCountry)),
+		Total:     total.count(),
+	}
+	for asn, g := range byASN {
+		st.ByASN[asn] = g.count()
+	}
+	for cc, g := range byCountry {
+		st.ByCountry[cc] = g.count()
+	}
+	return st
+}
+
+// RankBy extracts the value an AddressCount is ranked by.
+type RankBy func(c AddressCount) *big.Int
+
+// ByIPv4 ranks by the number of IPv4 addresses.
+func ByIPv4(c AddressCount) *big.Int {
+	return new(big.Int).SetUint64(c.IPv4)
+}
+
+// ByIPv6Blocks ranks by the number of IPv6 blocks of the given prefix length, see AddressCount.IPv6Blocks.
+func ByIPv6Blocks(bits int) RankBy {
+	return func(c AddressCount) *big.Int {
+		return c.IPv6Blocks(bits)
+	}
+}
+
+// Ranking is an entry of a ranking.
+type Ranking[K ordered] struct {
+	Key   K
+	Count AddressCount
+}
+
+type ordered interface {
+	~int | ~uint32 | ~string
+}
+
+// TopASN returns the n ASN holding the most address space by the given measure, n <= 0 returns all ASN.
+func (s *Stats) TopASN(n int, by RankBy) []Ranking[ASN] {
+	return rank(s.ByASN, n, by)
+}
+
+// TopCountries returns the n country codes holding the most address space by the given measure, n <= 0 returns all.
+func (s *Stats) TopCountries(n int, by RankBy) []Ranking[string] {
+	return rank(s.ByCountry, n, by)
+}
+
+// rank sorts the counts by descending value, and ascending key on ties.
+func rank[K ordered](m map[K]AddressCount, n int, by RankBy) []Ranking[K] {
+	type entry struct {
+		Ranking[K]
+		value *big.Int
+	}
+	entries := make([]entry, 0, len(m))
+	for k, c := range m {
+		entries = append(entries, entry{Ranking: Ranking[K]{Key: k, Count: c}, value: by(c)})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if cmp := entries[i].value.Cmp(entries[j].value); cmp != 0 {
+			return cmp > 0
+		}
+		return entries[i].Key < entries[j].Key
+	})
+
+	if n <= 0 || n > len(entries) {
+		n = len(entries)
+	}
+	s := make([]Ranking[K], n)
+	for i := range s {
+		s[i] = entries[i].Ranking
+	}
+	return s
+}
+
+// size4 returns the number of addresses in an IPv4 range.
+func (r keyRange) size4() uint64 {
+	return r.end.lo - r.start.lo + 1
+}
+
+// size6 returns the number of addresses in an IPv6 range, which may be 2^128.
+func (r keyRange) size6() *big.Int {
+	d := r.end.sub(r.start)
+	b := new(big.Int).SetUint64(d.hi)
+	b.Lsh(b, 64)
+	b.Or(b, new(big.Int).SetUint64(d.lo))
+	return b.Add(b, big.NewInt(1))
+}
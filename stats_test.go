@@ -0,0 +1,115 @@
+package asndb
+
+import (
+	"math/big"
+	"net/netip"
+	"reflect"
+	"testing"
+)
+
+func TestAS_AddressCount(t *testing.T) {
+	full, _ := new(big.Int).SetString("340282366920938463463374607431768211456", 10)
+	tests := []struct {
+		start string
+		end   string
+		ipv4  uint64
+		ipv6  *big.Int
+	}{
+		{start: "1.0.0.0", end: "1.0.0.255", ipv4: 256, ipv6: big.NewInt(0)},
+		{start: "10.0.0.5", end: "10.0.0.5", ipv4: 1, ipv6: big.NewInt(0)},
+		{start: "0.0.0.0", end: "255.255.255.255", ipv4: 1 << 32, ipv6: big.NewInt(0)},
+		{start: "2001:db8::", end: "2001:db8::ffff", ipv6: big.NewInt(1 << 16)},
+		{start: "::", end: "ffff:ffff:ffff:ffff:ffff:ffff:ffff:ffff", ipv6: full},
+		{start: "1.0.0.1", end: "1.0.0.0", ipv6: big.NewInt(0)},
+		{start: "1.0.0.1", end: "::1", ipv6: big.NewInt(0)},
+	}
+	for _, tt := range tests {
+		got := AS{StartIP: netip.MustParseAddr(tt.start), EndIP: netip.MustParseAddr(tt.end)}.AddressCount()
+		if got.IPv4 != tt.ipv4 || got.IPv6.Cmp(tt.ipv6) != 0 {
+			t.Errorf("AS{%v->%v}.AddressCount() = %v/%v, want %v/%v", tt.start, tt.end, got.IPv4, got.IPv6, tt.ipv4, tt.ipv6)
+		}
+	}
+}
+
+func TestAddressCount_IPv6Blocks(t *testing.T) {
+	c := AS{StartIP: netip.MustParseAddr("2001:db8::"), EndIP: netip.MustParseAddr("2001:db8:1:ffff:ffff:ffff:ffff:ffff")}.AddressCount()
+	for bits, want := range map[int]int64{32: 0, 47: 1, 48: 2, 64: 1 << 17} {
+		if got := c.IPv6Blocks(bits); got.Cmp(big.NewInt(want)) != 0 {
+			t.Errorf("AddressCount.IPv6Blocks(%d) = %v, want %v", bits, got, want)
+		}
+	}
+	if got := (AddressCount{}).IPv6Blocks(64); got.Sign() != 0 {
+		t.Errorf("AddressCount{}.IPv6Blocks(64) = %v, want 0", got)
+	}
+}
+
+func statsZones() []AS {
+	return []AS{
+		{StartIP: netip.MustParseAddr("1.0.0.0"), EndIP: netip.MustParseAddr("1.0.0.255"), ASNumber: 1, CountryCode: "US"},
+		//overlaps the zone above, only 1.0.1.0/24 is new
+		{StartIP: netip.MustParseAddr("1.0.0.128"), EndIP: netip.MustParseAddr("1.0.1.255"), ASNumber: 1, CountryCode: "US"},
+		{StartIP: netip.MustParseAddr("2.0.0.0"), EndIP: netip.MustParseAddr("2.0.3.255"), ASNumber: 2, CountryCode: "US"},
+		//claimed by another ASN as well, counted for both ASN but only once for the country
+		{StartIP: netip.MustParseAddr("2.0.0.0"), EndIP: netip.MustParseAddr("2.0.0.255"), ASNumber: 3, CountryCode: "US"},
+		{StartIP: netip.MustParseAddr("2001:db8::"), EndIP: netip.MustParseAddr("2001:db8:ffff:ffff:ffff:ffff:ffff:ffff"), ASNumber: 3, CountryCode: "JP"},
+		{StartIP: netip.MustParseAddr("2001:db9::"), EndIP: netip.MustParseAddr("2001:db9:0:ffff:ffff:ffff:ffff:ffff"), ASNumber: 1, CountryCode: "JP"},
+		{ASNumber: 4, CountryCode: "JP"},
+	}
+}
+
+func TestStats(t *testing.T) {
+	s := statsZones()
+	for name, st := range map[string]*Stats{"ASList": NewASList(s).Stats(), "ASNMap": NewASNMap(s).Stats()} {
+		t.Run(name, func(t *testing.T) {
+			wantASN := map[ASN][2]int64{1: {512, 1}, 2: {1024, 0}, 3: {256, 1 << 16}, 4: {0, 0}}
+			for asn, want := range wantASN {
+				c, ok := st.ByASN[asn]
+				if !ok || c.IPv4 != uint64(want[0]) || c.IPv6Blocks(48).Cmp(big.NewInt(want[1])) != 0 {
+					t.Errorf("Stats.ByASN[%d] = %v/%v, want %v/%v /48", asn, c.IPv4, c.IPv6Blocks(48), want[0], want[1])
+				}
+			}
+			if len(st.ByASN) != len(wantASN) {
+				t.Errorf("len(Stats.ByASN) = %d, want %d", len(st.ByASN), len(wantASN))
+			}
+			if c := st.ByCountry["US"]; c.IPv4 != 512+1024 || c.IPv6.Sign() != 0 {
+				t.Errorf("Stats.ByCountry[US] = %v/%v, want 1536/0", c.IPv4, c.IPv6)
+			}
+			if c := st.ByCountry["JP"]; c.IPv4 != 0 || c.IPv6Blocks(48).Cmp(big.NewInt(1<<16+1)) != 0 {
+				t.Errorf("Stats.ByCountry[JP] = %v/%v /48, want 0/%v /48", c.IPv4, c.IPv6Blocks(48), 1<<16+1)
+			}
+			if st.Total.IPv4 != 512+1024 || st.Total.IPv6Blocks(48).Cmp(big.NewInt(1<<16+1)) != 0 {
+				t.Errorf("Stats.Total = %v/%v /48, want 1536/%v /48", st.Total.IPv4, st.Total.IPv6Blocks(48), 1<<16+1)
+			}
+		})
+	}
+}
+
+func TestStats_Top(t *testing.T) {
+	st := NewASList(statsZones()).Stats()
+	keys := func(r []Ranking[ASN]) []ASN {
+		var s []ASN
+		for _, e := range r {
+			s = append(s, e.Key)
+		}
+		return s
+	}
+
+	if got, want := keys(st.TopASN(0, ByIPv4)), []ASN{2, 1, 3, 4}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Stats.TopASN(0, ByIPv4) = %v, want %v", got, want)
+	}
+	if got, want := keys(st.TopASN(2, ByIPv4)), []ASN{2, 1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Stats.TopASN(2, ByIPv4) = %v, want %v", got, want)
+	}
+	//ties are broken by ASN
+	if got, want := keys(st.TopASN(3, ByIPv6Blocks(48))), []ASN{3, 1, 2}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Stats.TopASN(3, ByIPv6Blocks(48)) = %v, want %v", got, want)
+	}
+	if got, want := keys(st.TopASN(10, ByIPv6Blocks(32))), []ASN{3, 1, 2, 4}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Stats.TopASN(10, ByIPv6Blocks(32)) = %v, want %v", got, want)
+	}
+
+	countries := st.TopCountries(1, ByIPv6Blocks(64))
+	if len(countries) != 1 || countries[0].Key != "JP" {
+		t.Errorf("Stats.TopCountries(1, ByIPv6Blocks(64)) = %v, want [JP]", countries)
+	}
+}
@@ -0,0 +1,118 @@
+package asndb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/netip"
+	"time"
+)
+
+// Check is a sanity lookup ran against newly loaded data, the IP must resolve to ASNumber.
+type Check struct {
+	IP       netip.Addr
+	ASNumber ASN
+}
+
+// ValidationError is returned when newly loaded data fails validation and did not get published.
+type ValidationError struct {
+	Reason string
+}
+
+func (e *ValidationError) Error() string {
+	return "validation failed: " + e.Reason
+}
+
+// Updater periodically loads data from Source, validates it, and only then publishes it to Registry.
+// Data failing validation is discarded and the Registry keeps serving the previous snapshot.
+type Updater struct {
+	Registry *Registry
+	Source   Source
+	// Interval is the delay between updates.
+	Interval time.Duration
+	// Jitter is the upper bound of a random delay added to every Interval.
+	Jitter time.Duration
+
+	// MinRows is the minimum number of AS zones required, data without any zones is always rejected.
+	MinRows int
+	// MinRatio is the minimum size of the new data relative to the current snapshot, 0.9 rejects data shrinking by over 10%.
+	MinRatio float64
+	// Checks are lookups that must succeed on the new data.
+	Checks []Check
+
+	// OnError is called with every error encountered by Run.
+	OnError func(error)
+}
+
+// NewUpdater creates a new Updater publishing data from src to r every interval.
+func NewUpdater(r *Registry, src Source, interval time.Duration) *Updater {
+	return &Updater{Registry: r, Source: src, Interval: interval}
+}
+
+// Update loads, validates and publishes data once.
+// Like Registry.Reload, a *LoadError from a lenient loader does not prevent publishing valid data,
+// and gets returned after publishing.
+// Concurrent updates of the same Registry, including Registry.Reload, are serialised from loading until publishing.
+func (u *Updater) Update(ctx context.Context) error {
+	u.Registry.loadMu.Lock()
+	defer u.Registry.loadMu.Unlock()
+	s, err := u.Source.Load(ctx)
+	var loadErr *LoadError
+	if err != nil && !errors.As(err, &loadErr) {
+		return err
+	}
+
+	snap := NewSnapshot(s)
+	if vErr := u.validate(snap); vErr != nil {
+		return vErr
+	}
+	u.Registry.swap(snap)
+	return err
+}
+
+func (u *Updater) validate(snap *Snapshot) error {
+	rows := snap.List.IndexLen()
+	if rows == 0 || rows < u.MinRows {
+		return &ValidationError{Reason: fmt.Sprintf("got %d rows, want at least %d", rows, u.MinRows)}
+	}
+	if u.MinRatio > 0 {
+		current := u.Registry.Snapshot().List.IndexLen()
+		if float64(rows) < float64(current)*u.MinRatio {
+			return &ValidationError{Reason: fmt.Sprintf("got %d rows, want at least %.0f%% of current %d rows",
+				rows, u.MinRatio*100, current)}
+		}
+	}
+	for _, c := range u.Checks {
+		as, found := snap.List.Find(c.IP)
+		if !found || as.ASNumber != c.ASNumber {
+			return &ValidationError{Reason: fmt.Sprintf("%s resolved to %v(found %t), want %s",
+				c.IP, as, found, c.ASNumber)}
+		}
+	}
+	return nil
+}
+
+// Run calls Update every Interval plus jitter until ctx is done, reporting errors to OnError.
+// The first update happens after the first interval, it returns ctx.Err().
+func (u *Updater) Run(ctx context.Context) error {
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	for {
+		delay := u.Interval
+		if u.Jitter > 0 {
+			delay += time.Duration(rnd.Int63n(int64(u.Jitter)))
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+
+		if err := u.Update(ctx); err != nil && u.OnError != nil {
+			u.OnError(err)
+		}
+	}
+}
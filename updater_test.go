@@ -0,0 +1,157 @@
+package asndb
+
+import (
+	"context"
+	"errors"
+	"net/netip"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestUpdater_Update(t *testing.T) {
+	initial := []AS{
+		{StartIP: netip.MustParseAddr("1.0.0.0"), EndIP: netip.MustParseAddr("1.0.0.255"), ASNumber: 1},
+		{StartIP: netip.MustParseAddr("1.0.1.0"), EndIP: netip.MustParseAddr("1.0.1.255"), ASNumber: 2},
+		{StartIP: netip.MustParseAddr("1.0.2.0"), EndIP: netip.MustParseAddr("1.0.2.255"), ASNumber: 3},
+	}
+	tsvSource := func(data string) Source {
+		return SourceFunc(func(ctx context.Context) ([]AS, error) {
+			return LoadFromTSVWithOptions(strings.NewReader(data), LoadOptions{Mode: LoadLenient})
+		})
+	}
+	checks := []Check{{IP: netip.MustParseAddr("1.0.0.1"), ASNumber: 13335}}
+
+	tests := []struct {
+		name        string
+		updater     Updater
+		wantErr     interface{}
+		wantPublish bool
+	}{
+		{
+			name:        "valid",
+			updater:     Updater{Source: tsvSource(testTSV), Checks: checks},
+			wantPublish: true,
+		}, {
+			name:    "empty",
+			updater: Updater{Source: tsvSource("")},
+			wantErr: new(*ValidationError),
+		}, {
+			name:    "min rows",
+			updater: Updater{Source: tsvSource(testTSV), MinRows: 3},
+			wantErr: new(*ValidationError),
+		}, {
+			name:    "min ratio",
+			updater: Updater{Source: tsvSource(testTSV), MinRatio: 0.9},
+			wantErr: new(*ValidationError),
+		}, {
+			name:    "failed check",
+			updater: Updater{Source: tsvSource(testTSV), Checks: []Check{{IP: netip.MustParseAddr("1.0.1.1"), ASNumber: 13335}}},
+			wantErr: new(*ValidationError),
+		}, {
+			name:        "lenient",
+			updater:     Updater{Source: tsvSource(testTSV + "foo\n"), Checks: checks},
+			wantErr:     new(*LoadError),
+			wantPublish: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewRegistry(initial)
+			before := r.Snapshot()
+			u := tt.updater
+			u.Registry = r
+
+			err := u.Update(context.Background())
+			if tt.wantErr == nil && err != nil {
+				t.Errorf("Updater.Update() error = %v", err)
+			}
+			if tt.wantErr != nil && !errors.As(err, tt.wantErr) {
+				t.Errorf("Updater.Update() error = %v, want %T", err, tt.wantErr)
+			}
+			if published := r.Snapshot() != before; published != tt.wantPublish {
+				t.Errorf("Updater.Update() published = %v, want %v", published, tt.wantPublish)
+			}
+		})
+	}
+}
+
+func TestUpdater_Run(t *testing.T) {
+	r := NewRegistry(nil)
+	calls := make(chan struct{}, 10)
+	u := NewUpdater(r, SourceFunc(func(ctx context.Context) ([]AS, error) {
+		select {
+		case calls <- struct{}{}:
+		default:
+		}
+		return nil, nil
+	}), time.Millisecond)
+	u.Jitter = time.Millisecond
+	errs := make(chan error, 10)
+	u.OnError = func(err error) {
+		select {
+		case errs <- err:
+		default:
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- u.Run(ctx)
+	}()
+	<-calls
+	<-calls
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Errorf("Updater.Run() = %v, want %v", err, context.Canceled)
+	}
+	var vErr *ValidationError
+	if err := <-errs; !errors.As(err, &vErr) {
+		t.Errorf("Updater.OnError() = %v, want *ValidationError", err)
+	}
+}
+
+func TestUpdater_UpdateConcurrent(t *testing.T) {
+	r := NewRegistry(nil)
+	var active, overlaps int32
+	var version uint32
+	src := SourceFunc(func(ctx context.Context) ([]AS, error) {
+		if atomic.AddInt32(&active, 1) > 1 {
+			atomic.AddInt32(&overlaps, 1)
+		}
+		defer atomic.AddInt32(&active, -1)
+		v := atomic.AddUint32(&version, 1)
+		time.Sleep(time.Millisecond)
+		return []AS{{StartIP: netip.MustParseAddr("1.0.0.0"), EndIP: netip.MustParseAddr("1.0.0.255"), ASNumber: ASN(v)}}, nil
+	})
+	u := NewUpdater(r, src, time.Hour)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			var err error
+			if i%2 == 0 {
+				err = u.Update(context.Background())
+			} else {
+				err = r.Reload(context.Background(), src)
+			}
+			if err != nil {
+				t.Errorf("Update() or Reload() error = %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if overlaps != 0 {
+		t.Errorf("Source.Load() overlapped %d times, want 0", overlaps)
+	}
+	//the last load is the one published
+	if as, found := r.Find(netip.MustParseAddr("1.0.0.1")); !found || uint32(as.ASNumber) != version {
+		t.Errorf("Registry.Find() = %v, %v, want AS%d", as, found, version)
+	}
+}